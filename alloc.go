@@ -0,0 +1,173 @@
+package rbt
+
+import (
+	"math/bits"
+	"unsafe"
+)
+
+const (
+	// listBlobs is the free list used for blocks and blobs
+	listBlobs = iota
+	// listOverflow is the free list used for overflow extents
+	listOverflow
+	// freeLists is the number of free lists
+	freeLists
+)
+
+const (
+	// freeClasses is the number of size classes tracked by the free lists
+	freeClasses = 48
+	// freeSearchLimit is the number of chunks checked when searching a size class for a fit
+	freeSearchLimit = 64
+	// freeChunkSize is the smallest chunk (in bytes) which can be tracked by the free lists
+	freeChunkSize = int64(unsafe.Sizeof(freeChunk{}))
+)
+
+// freeChunk is written to the start of every tracked free chunk
+type freeChunk struct {
+	next int64
+	size int64
+}
+
+// alloc will reserve sz bytes and return the offset of the reserved bytes
+// Note: Freed chunks are used before extending the tail
+func (t *Tree) alloc(sz int64) (offset int64, grew bool) {
+	return t.allocFrom(listBlobs, sz)
+}
+
+// allocOverflow will reserve an overflow extent of sz bytes and return the offset of the reserved bytes
+// Note: Overflow extents are reused separately from blocks and blobs
+func (t *Tree) allocOverflow(sz int64) (offset int64, grew bool) {
+	return t.allocFrom(listOverflow, sz)
+}
+
+// free will release sz bytes at the provided offset so they can be reused by alloc
+// Note: Chunks smaller than freeChunkSize cannot hold a free header and are not tracked
+func (t *Tree) free(offset, sz int64) {
+	t.freeTo(listBlobs, offset, sz)
+}
+
+// freeOverflow will release an overflow extent of sz bytes so it can be reused by allocOverflow
+func (t *Tree) freeOverflow(offset, sz int64) {
+	t.freeTo(listOverflow, offset, sz)
+}
+
+func (t *Tree) allocFrom(list int, sz int64) (offset int64, grew bool) {
+	if sz <= 0 {
+		// Nothing to reserve, empty allocations simply reference the tail
+		return t.t.tail, false
+	}
+
+	if offset = t.popFree(list, sz); offset != -1 {
+		return
+	}
+
+	offset = t.t.tail
+	if t.t.format == FormatCompact && offset+sz > maxCompactSize {
+		panic(ErrCompactLimit)
+	}
+
+	grew = t.grow(offset + sz)
+	t.t.tail += sz
+	return
+}
+
+func (t *Tree) freeTo(list int, offset, sz int64) {
+	if offset == -1 || sz <= 0 {
+		return
+	}
+
+	if offset+sz == t.t.tail {
+		// Chunk sits at the very end, we can simply pull the tail back
+		t.t.tail = offset
+		return
+	}
+
+	if sz < freeChunkSize {
+		return
+	}
+
+	// Free chunks are filed under the largest class they completely cover
+	class := bits.Len64(uint64(sz)) - 1
+	if class >= freeClasses {
+		class = freeClasses - 1
+	}
+
+	fc := t.getFreeChunk(offset)
+	fc.next = t.t.free[list][class]
+	fc.size = sz
+	t.t.free[list][class] = offset
+}
+
+// popFree will return a chunk from a free list which can hold sz bytes, -1 is returned if none exist
+func (t *Tree) popFree(list int, sz int64) (offset int64) {
+	class := bits.Len64(uint64(sz)) - 1
+	if offset = t.seekFree(list, class, sz); offset != -1 {
+		return
+	}
+
+	// Every chunk within the larger classes can hold the size, only the heads need to be checked
+	for class++; class < freeClasses; class++ {
+		if offset = t.t.free[list][class]; offset == -1 {
+			continue
+		}
+
+		fc := t.getFreeChunk(offset)
+		t.t.free[list][class] = fc.next
+		// Release the remainder of the chunk
+		t.freeTo(list, offset+sz, fc.size-sz)
+		return
+	}
+
+	return -1
+}
+
+// seekFree will remove and return a chunk which can hold sz bytes from a size class, -1 is returned if
+// none are found. Exact fits are preferred, otherwise the first chunk which can hold the size is used.
+// Note: Only the first freeSearchLimit chunks of the class are checked
+func (t *Tree) seekFree(list, class int, sz int64) (offset int64) {
+	match, matchPrev := int64(-1), int64(-1)
+	prev := int64(-1)
+	offset = t.t.free[list][class]
+	for i := 0; offset != -1 && i < freeSearchLimit; i++ {
+		fc := t.getFreeChunk(offset)
+		if fc.size == sz {
+			match, matchPrev = offset, prev
+			break
+		}
+
+		if match == -1 && fc.size > sz {
+			match, matchPrev = offset, prev
+		}
+
+		prev, offset = offset, fc.next
+	}
+
+	if match == -1 {
+		return -1
+	}
+
+	// Unlink the chunk from the class
+	fc := t.getFreeChunk(match)
+	if matchPrev == -1 {
+		t.t.free[list][class] = fc.next
+	} else {
+		t.getFreeChunk(matchPrev).next = fc.next
+	}
+
+	// Release the remainder of the chunk
+	t.freeTo(list, match+sz, fc.size-sz)
+	return match
+}
+
+func (t *Tree) getFreeChunk(offset int64) (fc *freeChunk) {
+	return (*freeChunk)(unsafe.Pointer(&t.bs[offset]))
+}
+
+func (t *Tree) resetFree() {
+	for list := range t.t.free {
+		for class := range t.t.free[list] {
+			t.t.free[list][class] = -1
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package rbt
+
+import (
+	"bytes"
+	"slices"
+)
+
+// PutBatch will insert a batch of items into the tree. The backend is grown once for the entire batch and
+// each insertion searches from the previous insertion point rather than from the root.
+// ErrIncompatibleValue is returned when a key of the batch holds a bucket, every key is checked before the
+// batch is written so no entries are written in that case.
+// Note: When a key is repeated, the last entry for the key is kept
+func (t *Tree) PutBatch(entries []Blob) (err error) {
+	if len(entries) == 0 {
+		return
+	}
+
+	if err = t.checkBatch(entries); err != nil {
+		return
+	}
+
+	order := getBatchOrder(entries)
+	t.reserve(entries)
+
+	finger := int64(-1)
+	for n := range entries {
+		i := n
+		if order != nil {
+			i = order[n].index
+		}
+
+		start := t.getHeader().root
+		if finger != -1 {
+			start = t.seekFinger(finger, entries[i].Key)
+		}
+
+		finger = t.put(start, entries[i].Key, entries[i].Val)
+	}
+
+	return
+}
+
+// checkBatch will return ErrIncompatibleValue when any key of a batch holds a bucket
+func (t *Tree) checkBatch(entries []Blob) (err error) {
+	if t.getHeader().root == -1 {
+		return
+	}
+
+	for _, e := range entries {
+		if !t.mayContain(e.Key) {
+			continue
+		}
+
+		if b := t.getBlock(t.seekBlock(t.getHeader().root, e.Key)); b != nil && b.flags&blockBucket != 0 {
+			return ErrIncompatibleValue
+		}
+	}
+
+	return
+}
+
+// getBatchOrder will return references to a batch of entries in key order, nil is returned when the
+// entries are already sorted. References are sorted rather than the entries, leaving the batch untouched.
+// Note: Repeated keys are ordered by their position, so the last entry for a key is written last
+func getBatchOrder(entries []Blob) (order []batchRef) {
+	if slices.IsSortedFunc(entries, func(a, b Blob) int {
+		return bytes.Compare(a.Key, b.Key)
+	}) {
+		return
+	}
+
+	// Sorting on the key abbreviations avoids reaching the keys for most comparisons
+	order = make([]batchRef, len(entries))
+	for i := range order {
+		order[i] = batchRef{abbr: getAbbr(entries[i].Key), index: i}
+	}
+
+	slices.SortFunc(order, func(a, b batchRef) int {
+		switch {
+		case a.abbr < b.abbr:
+			return -1
+		case a.abbr > b.abbr:
+			return 1
+		}
+
+		if c := bytes.Compare(entries[a.index].Key, entries[b.index].Key); c != 0 {
+			return c
+		}
+
+		return a.index - b.index
+	})
+
+	return
+}
+
+// batchRef references an entry of a batch by it's index
+type batchRef struct {
+	abbr  uint64
+	index int
+}
+
+// reserve will grow the backend once to hold a batch of entries
+// Note: The reservation is an estimate, alloc will still grow the backend if the batch exceeds it
+func (t *Tree) reserve(entries []Blob) {
+	sz := t.t.tail
+	for _, e := range entries {
+		vcap := int64(len(e.Val))
+		if isOverflow(vcap) {
+			vcap = getExtentSize(vcap)
+		}
+
+		// Entries are sized as if they were held in a blob, which is never smaller than an inline block
+		sz += getHeaderSize(0) + wideSlotSize + getBlobHeaderSize(0) + int64(len(e.Key)) + vcap
+	}
+
+	t.grow(sz)
+}
+
+// seekFinger will return the block to search for a key from, starting at a finger holding a lesser key. The
+// finger is walked up until reaching a block whose subtree bounds the key.
+func (t *Tree) seekFinger(finger int64, key []byte) (offset int64) {
+	offset = finger
+	for depth := 0; ; depth++ {
+		checkDepth(depth)
+		b := t.getBlock(offset)
+		switch b.ct {
+		case childRoot:
+			return
+		case childLeft:
+			// The parent of a left child is the upper bound of the child's subtree
+			if t.compareKey(key, t.getBlock(b.getParent())) == -1 {
+				return
+			}
+		}
+
+		offset = b.getParent()
+	}
+}
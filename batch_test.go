@@ -0,0 +1,199 @@
+package rbt
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/itsmontoya/rbt/backend"
+	"github.com/itsmontoya/rbt/testUtils"
+)
+
+func TestPutBatch(t *testing.T) {
+	gb := &testGrowBackend{Backend: backend.NewBytes()}
+	w, err := NewRaw(TrunkSize, gb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Existing keys are overwritten by the batch
+	for i := 0; i < 100; i++ {
+		w.Put([]byte(fmt.Sprintf("key-%04d", i*10)), []byte("existing"))
+	}
+
+	vals := make(map[string][]byte)
+	var entries []Blob
+	for _, i := range rand.Perm(1000) {
+		key := []byte(fmt.Sprintf("key-%04d", i))
+		// Alternate between inline, external and overflow values
+		val := bytes.Repeat(key, 1+i%3*300)
+		vals[string(key)] = val
+		entries = append(entries, Blob{Key: key, Val: val})
+	}
+
+	// Repeated keys keep their last entry
+	entries = append(entries, Blob{Key: []byte("key-0001"), Val: []byte("repeated")})
+	vals["key-0001"] = []byte("repeated")
+
+	gb.n = 0
+	if err = w.PutBatch(entries); err != nil {
+		t.Fatal(err)
+	}
+
+	if gb.n != 1 {
+		t.Fatalf("invalid number of grows, expected %d and received %d", 1, gb.n)
+	}
+
+	if err = testValidate(w); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Len() != len(vals) {
+		t.Fatalf("invalid length, expected %d and received %d", len(vals), w.Len())
+	}
+
+	for key, val := range vals {
+		if !bytes.Equal(w.Get([]byte(key)), val) {
+			t.Fatalf("invalid value for \"%s\"", key)
+		}
+	}
+}
+
+func TestPutBatchSorted(t *testing.T) {
+	w := New(TrunkSize)
+	// Sorted batches are written in their provided order, repeated keys keep their last entry
+	entries := []Blob{
+		{Key: []byte("a"), Val: []byte("1")},
+		{Key: []byte("b"), Val: []byte("2")},
+		{Key: []byte("b"), Val: []byte("3")},
+		{Key: []byte("c"), Val: []byte("4")},
+	}
+
+	if err := w.PutBatch(entries); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := testValidate(w); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Len() != 3 {
+		t.Fatalf("invalid length, expected %d and received %d", 3, w.Len())
+	}
+
+	if val := string(w.Get([]byte("b"))); val != "3" {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "3", val)
+	}
+}
+
+func TestPutBatchBucket(t *testing.T) {
+	w := New(TrunkSize)
+	if _, err := w.CreateBucket([]byte("c")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Batches holding the name of a bucket are rejected before any entry is written
+	entries := []Blob{
+		{Key: []byte("a"), Val: []byte("1")},
+		{Key: []byte("b"), Val: []byte("2")},
+		{Key: []byte("c"), Val: []byte("3")},
+	}
+
+	if err := w.PutBatch(entries); err != ErrIncompatibleValue {
+		t.Fatalf("invalid error, expected %v and received %v", ErrIncompatibleValue, err)
+	}
+
+	if w.Len() != 1 {
+		t.Fatalf("invalid length, expected %d and received %d", 1, w.Len())
+	}
+
+	if w.Bucket([]byte("c")) == nil {
+		t.Fatal("invalid bucket, expected the bucket to remain")
+	}
+}
+
+func BenchmarkTreePutBatch(b *testing.B) {
+	benchPutBatch(b, testBatch(testRandomListStr))
+	b.ReportAllocs()
+}
+
+func BenchmarkTreeSortedPutBatch(b *testing.B) {
+	var entries []Blob
+	for _, key := range testBulkList(10000) {
+		entries = append(entries, Blob{Key: key, Val: key})
+	}
+
+	benchPutBatch(b, entries)
+	b.ReportAllocs()
+}
+
+func BenchmarkTreeMMapPutBatch(b *testing.B) {
+	benchMMAPPutBatch(b, testRandomListStr, true)
+	b.ReportAllocs()
+}
+
+func BenchmarkTreeMMapPutBatchPut(b *testing.B) {
+	benchMMAPPutBatch(b, testRandomListStr, false)
+	b.ReportAllocs()
+}
+
+func benchPutBatch(b *testing.B, entries []Blob) {
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		tr := New(TrunkSize)
+		tr.PutBatch(entries)
+	}
+}
+
+// benchMMAPPutBatch will fill a new MMAP tree on each iteration, either as a batch or with individual puts
+func benchMMAPPutBatch(b *testing.B, s []testUtils.KV, batch bool) {
+	if err := os.MkdirAll("./test_data", 0755); err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll("./test_data")
+
+	entries := testBatch(s)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		os.Remove("./test_data/batch.db")
+		tr, err := NewMMAP("./test_data", "batch.db", TrunkSize)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if batch {
+			tr.PutBatch(entries)
+		} else {
+			for _, e := range entries {
+				tr.Put(e.Key, e.Val)
+			}
+		}
+
+		tr.Close()
+	}
+}
+
+// testBatch will return a batch holding the provided key/value pairs
+func testBatch(s []testUtils.KV) (entries []Blob) {
+	entries = make([]Blob, len(s))
+	for i, kv := range s {
+		entries[i] = Blob{Key: kv.Val, Val: kv.Val}
+	}
+
+	return
+}
+
+// testGrowBackend counts the number of times a backend has been grown
+type testGrowBackend struct {
+	backend.Backend
+	n int
+}
+
+func (g *testGrowBackend) Grow(sz int64) []byte {
+	g.n++
+	return g.Backend.Grow(sz)
+}
@@ -1,23 +1,63 @@
 package rbt
 
+import (
+	"math"
+	"unsafe"
+)
+
+const (
+	slotOffset = iota
+	slotParent
+	slotLeft
+	slotRight
+	// slotPrefix is only held by blocks whose key is prefix compressed
+	slotPrefix
+)
+
+const (
+	// blobOverflow holds the offset of the overflow extent holding the value, -1 when the value directly
+	// follows the key
+	blobOverflow = iota
+	blobKeyLen
+	blobValLen
+	// blobValCap holds the capacity of the value, this will only exceed the length for grown or appended values
+	blobValCap
+	// blobSlots is the number of slots within a blob header
+	blobSlots
+)
+
+const (
+	// wideSlotSize is the size (in bytes) of the slots of FormatWide trees
+	wideSlotSize = int64(unsafe.Sizeof(int64(0)))
+	// compactSlotSize is the size (in bytes) of the slots of FormatCompact trees
+	compactSlotSize = int64(unsafe.Sizeof(uint32(0)))
+	// maxCompactSize is the largest size (in bytes) a FormatCompact tree can reach, the largest compact
+	// slot value is reserved for -1
+	maxCompactSize = math.MaxUint32
+)
+
 // BlockAndBlob are friends
 type BlockAndBlob struct {
 	Block
 	Blob
 }
 
-// Block is a reference to a data block
+// Block is a reference to a data block. Each Block is followed by slots holding the offsets of the block,
+// it's parent, it's children and (for prefix compressed keys) it's prefix. Inline blocks then hold their
+// key and value, all other blocks hold the offset of their blob. Slots are int64 values, or uint32 values
+// for the blocks of FormatCompact trees.
 type Block struct {
-	c  color
-	ct childType
+	c     color
+	ct    childType
+	flags blockFlag
 
-	offset     int64
-	blobOffset int64
-	parent     int64
-	children   [2]int64
+	// Lengths of an inline key and value, these are unused for blocks which hold a blob
+	inlineKeyLen uint8
+	inlineValLen uint8
+	inlineValCap uint8
 
-	keyLen int64
-	valLen int64
+	// First bytes of the full key, big-endian and zero padded, allowing most comparisons to skip the key
+	abbr uint64
 }
 
 // Blob represents a Key/Value entry
@@ -26,3 +66,130 @@ type Blob struct {
 	Key []byte
 	Val []byte
 }
+
+func (b *Block) getOffset() int64 {
+	return b.getSlot(slotOffset)
+}
+
+func (b *Block) setOffset(offset int64) {
+	b.setSlot(slotOffset, offset)
+}
+
+func (b *Block) getParent() int64 {
+	return b.getSlot(slotParent)
+}
+
+func (b *Block) setParent(offset int64) {
+	b.setSlot(slotParent, offset)
+}
+
+// getChild will return the offset of the left (0) or right (1) child
+func (b *Block) getChild(side int) int64 {
+	return b.getSlot(slotLeft + side)
+}
+
+// setChild will set the offset of the left (0) or right (1) child
+func (b *Block) setChild(side int, offset int64) {
+	b.setSlot(slotLeft+side, offset)
+}
+
+func (b *Block) getChildren() [2]int64 {
+	return [2]int64{b.getChild(0), b.getChild(1)}
+}
+
+// getPrefixOffset will return the offset of the shared key prefix, -1 is returned when the key is stored in full
+func (b *Block) getPrefixOffset() int64 {
+	if b.flags&blockPrefixed == 0 {
+		return -1
+	}
+
+	return b.getSlot(slotPrefix)
+}
+
+// getBlobOffset will return the offset of the blob for blocks which are not inline, -1 is returned until
+// the value has been set
+func (b *Block) getBlobOffset() int64 {
+	return b.getSlot(b.getDataSlot())
+}
+
+func (b *Block) setBlobOffset(offset int64) {
+	b.setSlot(b.getDataSlot(), offset)
+}
+
+// hasValue will return whether or not the value of the block has been set
+func (b *Block) hasValue() bool {
+	if b.flags&blockInline != 0 {
+		return b.flags&blockValue != 0
+	}
+
+	return b.getBlobOffset() != -1
+}
+
+// getDataSlot will return the slot following the offsets, which holds either the inline key or the blob offset
+func (b *Block) getDataSlot() int {
+	return getDataSlot(b.flags)
+}
+
+// getHeaderSize will return the size (in bytes) of the block and it's slots
+func (b *Block) getHeaderSize() int64 {
+	return getHeaderSize(b.flags)
+}
+
+func (b *Block) getSlot(slot int) int64 {
+	return readSlot(unsafe.Add(unsafe.Pointer(b), BlockSize+int64(slot)*getSlotSize(b.flags)), b.flags)
+}
+
+func (b *Block) setSlot(slot int, offset int64) {
+	writeSlot(unsafe.Add(unsafe.Pointer(b), BlockSize+int64(slot)*getSlotSize(b.flags)), b.flags, offset)
+}
+
+// getDataSlot will return the data slot of a block with the provided flags
+func getDataSlot(flags blockFlag) int {
+	if flags&blockPrefixed != 0 {
+		return slotPrefix + 1
+	}
+
+	return slotPrefix
+}
+
+// getHeaderSize will return the size (in bytes) of a block with the provided flags and it's slots
+func getHeaderSize(flags blockFlag) int64 {
+	return BlockSize + int64(getDataSlot(flags))*getSlotSize(flags)
+}
+
+// getSlotSize will return the size (in bytes) of the slots of a block with the provided flags
+func getSlotSize(flags blockFlag) int64 {
+	if flags&blockCompact != 0 {
+		return compactSlotSize
+	}
+
+	return wideSlotSize
+}
+
+// getBlobHeaderSize will return the size (in bytes) of the blob header of a block with the provided flags
+func getBlobHeaderSize(flags blockFlag) int64 {
+	return blobSlots * getSlotSize(flags)
+}
+
+// readSlot will read the slot at the provided pointer, compact slots holding the largest uint32 are read as -1
+func readSlot(p unsafe.Pointer, flags blockFlag) int64 {
+	if flags&blockCompact == 0 {
+		return *(*int64)(p)
+	}
+
+	if v := *(*uint32)(p); v != math.MaxUint32 {
+		return int64(v)
+	}
+
+	return -1
+}
+
+// writeSlot will write the slot at the provided pointer, -1 is written to compact slots as the largest uint32
+func writeSlot(p unsafe.Pointer, flags blockFlag, v int64) {
+	if flags&blockCompact == 0 {
+		*(*int64)(p) = v
+		return
+	}
+
+	*(*uint32)(p) = uint32(v)
+}
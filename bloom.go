@@ -0,0 +1,147 @@
+package rbt
+
+import (
+	"math"
+	"unsafe"
+)
+
+const (
+	// BloomSize is the size (in bytes) of the Bloom filter headers
+	BloomSize = int64(unsafe.Sizeof(bloom{}))
+	// defaultFalsePositiveRate is used when an invalid false positive rate is provided
+	defaultFalsePositiveRate = 0.01
+)
+
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+// bloom is written to the start of every Bloom filter and is followed by the filter bits
+type bloom struct {
+	// Number of keys the filter has been sized for
+	n int64
+	// Number of bits and hash functions
+	m int64
+	k int64
+	// False positive rate the filter has been sized for
+	p float64
+}
+
+// EnableBloomFilter will enable a Bloom filter for the tree, sized for n keys with a false positive rate
+// of p. Get and Has will return early for keys which the filter reports as absent. The filter is populated
+// with the current keys and is persisted alongside the tree.
+// Note: Keys cannot be removed from a Bloom filter. RebuildBloomFilter should be called after removing a
+// large number of keys or once the tree has grown beyond n keys.
+func (t *Tree) EnableBloomFilter(n int, p float64) {
+	t.DisableBloomFilter()
+	if n < 1 {
+		n = 1
+	}
+
+	if p <= 0 || p >= 1 {
+		p = defaultFalsePositiveRate
+	}
+
+	m := int64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	k := max(1, int64(math.Round(float64(m)/float64(n)*math.Ln2)))
+	sz := BloomSize + (m+7)/8
+
+	offset, _ := t.alloc(sz)
+	f := t.getBloom(offset)
+	f.n = int64(n)
+	f.m = m
+	f.k = k
+	f.p = p
+	clear(t.bs[offset+BloomSize : offset+sz])
+	t.getHeader().bloom = offset
+
+	for offset := t.seekStart(nil); offset != -1; offset = t.getNext(offset) {
+		t.addBloom(t.getBlock(offset))
+	}
+}
+
+// RebuildBloomFilter will rebuild the Bloom filter from the current keys, clearing any deleted keys. The
+// filter is resized when the tree holds more keys than it has been sized for.
+func (t *Tree) RebuildBloomFilter() {
+	offset := t.getHeader().bloom
+	if offset == -1 {
+		return
+	}
+
+	f := t.getBloom(offset)
+	t.EnableBloomFilter(max(int(f.n), t.Len()), f.p)
+}
+
+// DisableBloomFilter will disable and release the Bloom filter for the tree
+func (t *Tree) DisableBloomFilter() {
+	h := t.getHeader()
+	if h.bloom == -1 {
+		return
+	}
+
+	t.free(h.bloom, t.getBloomSize(h.bloom))
+	h.bloom = -1
+}
+
+// addBloom will add a block's key to the Bloom filter
+func (t *Tree) addBloom(b *Block) {
+	offset := t.getHeader().bloom
+	if offset == -1 {
+		return
+	}
+
+	f := t.getBloom(offset)
+	bits := t.bs[offset+BloomSize:]
+	h := fnvAppend(fnvAppend(fnvOffset, t.getPrefix(b)), t.getStoredKey(b))
+	for i, h1, h2 := int64(0), h&math.MaxUint32, h>>32; i < f.k; i++ {
+		bit := (h1 + uint64(i)*h2) % uint64(f.m)
+		bits[bit/8] |= 1 << (bit % 8)
+	}
+}
+
+// mayContain will return false when the Bloom filter reports the key as absent, true is always returned
+// when the tree does not have a Bloom filter
+func (t *Tree) mayContain(key []byte) bool {
+	offset := t.getHeader().bloom
+	if offset == -1 {
+		return true
+	}
+
+	f := t.getBloom(offset)
+	bits := t.bs[offset+BloomSize:]
+	h := fnvAppend(fnvOffset, key)
+	for i, h1, h2 := int64(0), h&math.MaxUint32, h>>32; i < f.k; i++ {
+		bit := (h1 + uint64(i)*h2) % uint64(f.m)
+		if bits[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// resetBloom will clear the Bloom filter for the tree while keeping it enabled
+func (t *Tree) resetBloom() {
+	if offset := t.getHeader().bloom; offset != -1 {
+		clear(t.bs[offset+BloomSize : offset+t.getBloomSize(offset)])
+	}
+}
+
+func (t *Tree) getBloom(offset int64) (f *bloom) {
+	return (*bloom)(unsafe.Pointer(&t.bs[offset]))
+}
+
+func (t *Tree) getBloomSize(offset int64) int64 {
+	return BloomSize + (t.getBloom(offset).m+7)/8
+}
+
+// fnvAppend will return the FNV-1a hash of bs, continuing from the provided hash
+func fnvAppend(h uint64, bs []byte) uint64 {
+	for _, c := range bs {
+		h ^= uint64(c)
+		h *= fnvPrime
+	}
+
+	return h
+}
@@ -0,0 +1,97 @@
+package rbt
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestBloomFilter(t *testing.T) {
+	var err error
+	if err = os.MkdirAll("./test_data", 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll("./test_data")
+
+	var w *Tree
+	if w, err = NewMMAP("./test_data", "bloom.db", 1024); err != nil {
+		t.Fatal(err)
+	}
+
+	w.Put([]byte("existing"), []byte("value"))
+	w.EnableBloomFilter(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		w.Put([]byte(fmt.Sprintf("key-%d", i)), []byte("value"))
+	}
+
+	if !w.Has([]byte("existing")) || !w.Has([]byte("key-500")) {
+		t.Fatal("invalid result, expected keys to exist")
+	}
+
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The filter is persisted alongside the tree
+	if w, err = NewMMAP("./test_data", "bloom.db", 1024); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	if w.getHeader().bloom == -1 {
+		t.Fatal("invalid bloom filter, expected the filter to be persisted")
+	}
+
+	var positives int
+	for i := 0; i < 10000; i++ {
+		key := []byte(fmt.Sprintf("missing-%d", i))
+		if w.mayContain(key) {
+			positives++
+		}
+
+		if w.Has(key) || w.Get(key) != nil {
+			t.Fatalf("invalid result, expected \"%s\" to not exist", key)
+		}
+	}
+
+	if positives > 500 {
+		t.Fatalf("invalid number of false positives, expected no more than %d and received %d", 500, positives)
+	}
+
+	// Deleted keys remain within the filter until it is rebuilt
+	for i := 0; i < 1000; i++ {
+		w.Delete([]byte(fmt.Sprintf("key-%d", i)))
+	}
+
+	if w.Has([]byte("key-500")) {
+		t.Fatal("invalid result, expected deleted key to not exist")
+	}
+
+	w.RebuildBloomFilter()
+	positives = 0
+	for i := 0; i < 1000; i++ {
+		if w.mayContain([]byte(fmt.Sprintf("key-%d", i))) {
+			positives++
+		}
+	}
+
+	if positives > 50 {
+		t.Fatalf("invalid number of false positives, expected no more than %d and received %d", 50, positives)
+	}
+
+	if !w.Has([]byte("existing")) {
+		t.Fatal("invalid result, expected key to exist after rebuilding the filter")
+	}
+
+	// Resetting keeps the filter enabled
+	w.Reset()
+	w.Put([]byte("after-reset"), []byte("value"))
+	if w.getHeader().bloom == -1 || !w.Has([]byte("after-reset")) {
+		t.Fatal("invalid bloom filter, expected the filter to remain enabled after a reset")
+	}
+
+	w.DisableBloomFilter()
+	if !w.Has([]byte("after-reset")) {
+		t.Fatal("invalid result, expected key to exist after disabling the filter")
+	}
+}
@@ -0,0 +1,100 @@
+package rbt
+
+// Bucket will return the bucket matching the provided name, nil is returned if the bucket does not exist
+// Note: The returned tree shares the backend of it's parent and is invalidated once the bucket is deleted
+func (t *Tree) Bucket(name []byte) (b *Tree) {
+	offset := t.seekBlock(t.getHeader().root, name)
+	if offset == -1 {
+		return
+	}
+
+	block := t.getBlock(offset)
+	if block.flags&blockBucket == 0 {
+		return
+	}
+
+	return t.newBucket(block)
+}
+
+// CreateBucket will create a new bucket with the provided name, ErrIncompatibleValue is returned when the
+// name is held by a value
+// Note: Buckets can be created within other buckets. A bucket is held as a key of it's parent, iterating the
+// parent will include the bucket with a nil value. Writing a value to the key of a bucket will panic with
+// ErrIncompatibleValue (PutBatch returns it before writing the batch), while deleting the key will delete the
+// bucket.
+func (t *Tree) CreateBucket(name []byte) (b *Tree, err error) {
+	offset := t.createBlock(name, HeaderSize)
+	block := t.getBlock(offset)
+	switch {
+	case block.flags&blockBucket != 0:
+		return nil, ErrBucketExists
+	case block.hasValue():
+		return nil, ErrIncompatibleValue
+	}
+
+	// Allocate a zeroed header for the bucket as the value
+	if grew := t.growBlob(block, name, HeaderSize); grew {
+		block = t.getBlock(offset)
+	}
+
+	t.setValLen(block, HeaderSize)
+	block.flags |= blockBucket
+	t.insertBalance(block)
+
+	b = t.newBucket(block)
+	b.getHeader().root = -1
+	b.getHeader().bloom = -1
+	return
+}
+
+// DeleteBucket will remove a bucket and all of it's contents, including any nested buckets
+func (t *Tree) DeleteBucket(name []byte) (err error) {
+	offset := t.seekBlock(t.getHeader().root, name)
+	if offset == -1 {
+		return ErrBucketNotFound
+	}
+
+	b := t.getBlock(offset)
+	if b.flags&blockBucket == 0 {
+		return ErrIncompatibleValue
+	}
+
+	// The contents of the bucket are released along with it's block
+	t.deleteBlock(b)
+	return
+}
+
+// ListBuckets will return the names of all the buckets directly within this tree in sorted order
+func (t *Tree) ListBuckets() (names [][]byte) {
+	for offset := t.seekStart(nil); offset != -1; offset = t.getNext(offset) {
+		if b := t.getBlock(offset); b.flags&blockBucket != 0 {
+			names = append(names, append([]byte(nil), t.getKey(b)...))
+		}
+	}
+
+	return
+}
+
+// newBucket will return a tree for the bucket header stored as the value of the provided block
+func (t *Tree) newBucket(b *Block) (bucket *Tree) {
+	// Buckets which are nested deeper than any tree can be have encountered a cycle
+	checkDepth(t.depth + 1)
+	return &Tree{storage: t.storage, h: t.getValueIndex(b), depth: t.depth + 1}
+}
+
+// freeBucket will release every item and nested bucket within a bucket
+func (t *Tree) freeBucket() {
+	h := t.getHeader()
+	// Nested buckets are released by freeBlock as their blocks are released
+	t.freeTree(h.root, 0)
+	h.root = -1
+	h.cnt = 0
+	t.resetBloom()
+}
+
+// checkValue will panic with ErrIncompatibleValue when a block holds a bucket rather than a value
+func checkValue(b *Block) {
+	if b.flags&blockBucket != 0 {
+		panic(ErrIncompatibleValue)
+	}
+}
@@ -0,0 +1,249 @@
+package rbt
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestBucket(t *testing.T) {
+	w := New(1024)
+	w.Put([]byte("hello"), []byte("root"))
+
+	users, err := w.CreateBucket([]byte("users"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orgs, err := w.CreateBucket([]byte("orgs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = w.CreateBucket([]byte("users")); err != ErrBucketExists {
+		t.Fatalf("invalid error, expected %v and received %v", ErrBucketExists, err)
+	}
+
+	for i := 0; i < 100; i++ {
+		key := []byte(fmt.Sprintf("user/%03d", i))
+		users.Put(key, []byte("a user value"))
+		orgs.Put([]byte(fmt.Sprintf("org/%03d", i)), []byte("org"))
+	}
+
+	users.Put([]byte("hello"), []byte("users"))
+
+	if val := string(w.Get([]byte("hello"))); val != "root" {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "root", val)
+	}
+
+	if val := string(w.Bucket([]byte("users")).Get([]byte("hello"))); val != "users" {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "users", val)
+	}
+
+	if val := string(orgs.Get([]byte("org/042"))); val != "org" {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "org", val)
+	}
+
+	if w.Len() != 3 || users.Len() != 101 || orgs.Len() != 100 {
+		t.Fatalf("invalid lengths, expected 3/101/100 and received %d/%d/%d", w.Len(), users.Len(), orgs.Len())
+	}
+
+	// Buckets are keys of their parent and are iterated with a nil value
+	var keys []string
+	w.ForEach(func(key, val []byte) (end bool) {
+		if string(key) != "hello" && val != nil {
+			t.Fatalf("invalid value, expected nil for bucket \"%s\" and received \"%s\"", key, val)
+		}
+
+		keys = append(keys, string(key))
+		return
+	})
+
+	if fmt.Sprint(keys) != "[hello orgs users]" {
+		t.Fatalf("invalid keys, expected [hello orgs users] and received %v", keys)
+	}
+
+	if _, err = w.CreateBucket([]byte("hello")); err != ErrIncompatibleValue {
+		t.Fatalf("invalid error, expected %v and received %v", ErrIncompatibleValue, err)
+	}
+
+	if err = w.DeleteBucket([]byte("hello")); err != ErrIncompatibleValue {
+		t.Fatalf("invalid error, expected %v and received %v", ErrIncompatibleValue, err)
+	}
+
+	if w.Get([]byte("users")) != nil || !w.Has([]byte("users")) || w.CompareAndSwap([]byte("users"), nil, []byte("value")) {
+		t.Fatal("invalid bucket, expected bucket to hold no value")
+	}
+
+	func() {
+		defer func() {
+			if err := recover(); err != ErrIncompatibleValue {
+				t.Fatalf("invalid panic, expected %v and received %v", ErrIncompatibleValue, err)
+			}
+		}()
+
+		w.Put([]byte("users"), []byte("value"))
+	}()
+
+	if names := w.ListBuckets(); len(names) != 2 || string(names[0]) != "orgs" || string(names[1]) != "users" {
+		t.Fatalf("invalid bucket names, expected [orgs users] and received %s", names)
+	}
+
+	size := w.Size()
+	if err = w.DeleteBucket([]byte("users")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = w.DeleteBucket([]byte("users")); err != ErrBucketNotFound {
+		t.Fatalf("invalid error, expected %v and received %v", ErrBucketNotFound, err)
+	}
+
+	if w.Bucket([]byte("users")) != nil {
+		t.Fatal("invalid bucket, expected bucket to be deleted")
+	}
+
+	// Space released by the deleted bucket should be reused
+	users, _ = w.CreateBucket([]byte("users"))
+	for i := 0; i < 100; i++ {
+		users.Put([]byte(fmt.Sprintf("user/%03d", i)), []byte("a user value"))
+	}
+
+	if w.Size() > size {
+		t.Fatalf("invalid size, expected no more than %d and received %d", size, w.Size())
+	}
+
+	if val := string(orgs.Get([]byte("org/099"))); val != "org" {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "org", val)
+	}
+}
+
+func TestNestedBucket(t *testing.T) {
+	w := New(1024)
+	val := []byte("a document value")
+
+	create := func() {
+		tenant, err := w.CreateBucket([]byte("tenant-1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 10; i++ {
+			var collection *Tree
+			if collection, err = tenant.CreateBucket([]byte(fmt.Sprintf("collection-%d", i))); err != nil {
+				t.Fatal(err)
+			}
+
+			for j := 0; j < 100; j++ {
+				collection.Put([]byte(fmt.Sprintf("document-%03d", j)), val)
+			}
+		}
+	}
+
+	create()
+
+	tenant := w.Bucket([]byte("tenant-1"))
+	if names := tenant.ListBuckets(); len(names) != 10 {
+		t.Fatalf("invalid number of buckets, expected %d and received %d", 10, len(names))
+	}
+
+	collection := tenant.Bucket([]byte("collection-7"))
+	if got := string(collection.Get([]byte("document-042"))); got != string(val) {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", string(val), got)
+	}
+
+	if tenant.Len() != 10 || collection.Len() != 100 {
+		t.Fatalf("invalid lengths, expected 10/100 and received %d/%d", tenant.Len(), collection.Len())
+	}
+
+	if w.Bucket([]byte("collection-7")) != nil {
+		t.Fatal("invalid bucket, expected nested bucket to only be available from it's parent")
+	}
+
+	size := w.Size()
+	if err := w.DeleteBucket([]byte("tenant-1")); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Has([]byte("tenant-1")) {
+		t.Fatal("invalid key, expected bucket key to be removed along with the bucket")
+	}
+
+	// Everything beneath the deleted bucket should have been released
+	create()
+	if w.Size() > size {
+		t.Fatalf("invalid size, expected no more than %d and received %d", size, w.Size())
+	}
+
+	// Deleting the key of a bucket through the tree will release the bucket as well
+	w.DeleteRange(nil, nil)
+	create()
+	if w.Size() > size {
+		t.Fatalf("invalid size, expected no more than %d and received %d", size, w.Size())
+	}
+
+	// Resetting a bucket should only clear the bucket
+	tenant = w.Bucket([]byte("tenant-1"))
+	tenant.Reset()
+	if names := tenant.ListBuckets(); len(names) != 0 {
+		t.Fatalf("invalid number of buckets, expected %d and received %d", 0, len(names))
+	}
+
+	if w.Bucket([]byte("tenant-1")) == nil {
+		t.Fatal("invalid bucket, expected bucket to remain after reset")
+	}
+}
+
+func TestBucketMMAP(t *testing.T) {
+	var err error
+	if err = os.MkdirAll("./test_data", 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll("./test_data")
+
+	var tr *Tree
+	if tr, err = NewMMAP("./test_data", "mmap.db", 64); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		var b *Tree
+		if b, err = tr.CreateBucket([]byte(fmt.Sprintf("bucket-%d", i))); err != nil {
+			t.Fatal(err)
+		}
+
+		for j := 0; j < 100; j++ {
+			b.Put([]byte(fmt.Sprintf("%03d", j)), []byte(fmt.Sprintf("%d/%d", i, j)))
+		}
+
+		// Closing a bucket leaves the shared backend open
+		if err = b.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tr.Put([]byte("after"), []byte("close"))
+	tr.Close()
+
+	if tr, err = NewMMAP("./test_data", "mmap.db", 64); err != nil {
+		t.Fatal(err)
+	}
+	defer tr.Close()
+
+	if val := string(tr.Get([]byte("after"))); val != "close" {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "close", val)
+	}
+
+	for i := 0; i < 10; i++ {
+		b := tr.Bucket([]byte(fmt.Sprintf("bucket-%d", i)))
+		if b == nil {
+			t.Fatalf("invalid bucket, expected bucket-%d to exist", i)
+		}
+
+		for j := 0; j < 100; j++ {
+			expected := fmt.Sprintf("%d/%d", i, j)
+			if val := string(b.Get([]byte(fmt.Sprintf("%03d", j)))); val != expected {
+				t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", expected, val)
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package rbt
+
+import (
+	"math/bits"
+
+	"github.com/itsmontoya/rbt/backend"
+	"github.com/missionMeteora/toolkit/errors"
+)
+
+const (
+	// ErrUnsortedKeys is returned when bulk loaded keys are not in ascending order
+	ErrUnsortedKeys = errors.Error("keys must be provided in ascending order")
+	// ErrTreeNotEmpty is returned when bulk loading into a backend which already holds entries
+	ErrTreeNotEmpty = errors.Error("tree is not empty")
+)
+
+// BulkLoad will return a new Tree holding the entries provided by next, which is called until it returns
+// false. Entries are written in key order and linked into a perfectly balanced tree in a single pass,
+// avoiding the descents and rotations of individual puts.
+// Note: Keys must be unique and in ascending order, otherwise ErrUnsortedKeys is returned and the loaded
+// entries are released. The backend must not hold any entries.
+func BulkLoad(b backend.Backend, next func() (key, val []byte, ok bool)) (t *Tree, err error) {
+	if t, err = NewRaw(TrunkSize, b); err != nil {
+		return
+	}
+
+	if t.getHeader().root != -1 {
+		return nil, ErrTreeNotEmpty
+	}
+
+	var offsets []int64
+	for {
+		key, val, ok := next()
+		if !ok {
+			break
+		}
+
+		if len(offsets) > 0 && t.compareKey(key, t.getBlock(offsets[len(offsets)-1])) != 1 {
+			for _, offset := range offsets {
+				t.freeBlock(t.getBlock(offset))
+			}
+
+			return nil, ErrUnsortedKeys
+		}
+
+		nb, offset, _ := t.newBlock(key, int64(len(val)), -1)
+		nb.abbr = getAbbr(key)
+		t.setBlob(nb, key, val)
+		offsets = append(offsets, offset)
+	}
+
+	h := t.getHeader()
+	// Every level above the last is full, blocks on the last level are painted red
+	h.root = t.linkBalanced(offsets, 0, bits.Len(uint(len(offsets)+1))-1)
+	h.cnt = int64(len(offsets))
+	return
+}
+
+// linkBalanced will link a sorted list of blocks into a balanced tree and return it's root. Blocks at or
+// below the provided depth are painted red, all others are painted black.
+func (t *Tree) linkBalanced(offsets []int64, depth, red int) (root int64) {
+	if len(offsets) == 0 {
+		return -1
+	}
+
+	mid := len(offsets) / 2
+	left := t.linkBalanced(offsets[:mid], depth+1, red)
+	right := t.linkBalanced(offsets[mid+1:], depth+1, red)
+
+	b := t.getBlock(offsets[mid])
+	b.c = colorBlack
+	if depth >= red {
+		b.c = colorRed
+	}
+
+	t.setChildren(b, left, right)
+	return b.getOffset()
+}
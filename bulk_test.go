@@ -0,0 +1,135 @@
+package rbt
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/itsmontoya/rbt/backend"
+)
+
+func TestBulkLoad(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 3, 7, 8, 1000} {
+		var i int
+		w, err := BulkLoad(backend.NewBytes(), func() (key, val []byte, ok bool) {
+			if i == n {
+				return
+			}
+
+			key = []byte(fmt.Sprintf("key-%06d", i))
+			// Alternate between inline, external and overflow values
+			val = bytes.Repeat(key, 1+i%3*300)
+			i++
+			return key, val, true
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err = testValidate(w); err != nil {
+			t.Fatalf("invalid tree for %d entries: %v", n, err)
+		}
+
+		if w.Len() != n {
+			t.Fatalf("invalid length, expected %d and received %d", n, w.Len())
+		}
+
+		for i = 0; i < n; i++ {
+			key := []byte(fmt.Sprintf("key-%06d", i))
+			if !bytes.Equal(w.Get(key), bytes.Repeat(key, 1+i%3*300)) {
+				t.Fatalf("invalid value for \"%s\"", key)
+			}
+		}
+
+		// The tree remains writable after being loaded
+		w.Put([]byte("key-"), []byte("first"))
+		for i = 0; i < n; i += 2 {
+			w.Delete([]byte(fmt.Sprintf("key-%06d", i)))
+		}
+
+		if err = testValidate(w); err != nil {
+			t.Fatal(err)
+		}
+
+		if expected := n/2 + 1; w.Len() != expected {
+			t.Fatalf("invalid length, expected %d and received %d", expected, w.Len())
+		}
+	}
+}
+
+func TestBulkLoadUnsorted(t *testing.T) {
+	keys := []string{"a", "c", "b"}
+	bs := backend.NewBytes()
+	_, err := BulkLoad(bs, func() (key, val []byte, ok bool) {
+		if len(keys) == 0 {
+			return
+		}
+
+		key = []byte(keys[0])
+		keys = keys[1:]
+		return key, key, true
+	})
+
+	if err != ErrUnsortedKeys {
+		t.Fatalf("invalid error, expected %v and received %v", ErrUnsortedKeys, err)
+	}
+
+	// The backend may be reused once the failed load has been released
+	var w *Tree
+	if w, err = NewRaw(TrunkSize, bs); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Len() != 0 {
+		t.Fatalf("invalid length, expected %d and received %d", 0, w.Len())
+	}
+
+	w.Put([]byte("key"), []byte("value"))
+	if _, err = BulkLoad(bs, func() (key, val []byte, ok bool) { return }); err != ErrTreeNotEmpty {
+		t.Fatalf("invalid error, expected %v and received %v", ErrTreeNotEmpty, err)
+	}
+}
+
+func BenchmarkBulkLoad(b *testing.B) {
+	kvs := testBulkList(10000)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var j int
+		BulkLoad(backend.NewBytes(), func() (key, val []byte, ok bool) {
+			if j == len(kvs) {
+				return
+			}
+
+			j++
+			return kvs[j-1], kvs[j-1], true
+		})
+	}
+
+	b.ReportAllocs()
+}
+
+func BenchmarkBulkLoadPut(b *testing.B) {
+	kvs := testBulkList(10000)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		tr := New(TrunkSize)
+		for _, kv := range kvs {
+			tr.Put(kv, kv)
+		}
+	}
+
+	b.ReportAllocs()
+}
+
+// testBulkList will return n keys in ascending order
+func testBulkList(n int) (kvs [][]byte) {
+	kvs = make([][]byte, n)
+	for i := range kvs {
+		kvs[i] = []byte(fmt.Sprintf("%08d", i))
+	}
+
+	return
+}
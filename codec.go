@@ -0,0 +1,191 @@
+package rbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"time"
+
+	"github.com/missionMeteora/toolkit/errors"
+)
+
+const (
+	// ErrInvalidEncoding is returned when encoded bytes cannot be decoded by a codec
+	ErrInvalidEncoding = errors.Error("invalid encoding")
+)
+
+const (
+	signBit = 1 << 63
+	// timeKeySize is the size (in bytes) of an encoded time key, seconds followed by nanoseconds
+	timeKeySize = 12
+)
+
+// KeyCodec will encode and decode keys for a Typed tree
+// Note: Encoded keys must sort (using bytes.Compare) in the same order as the keys themselves
+type KeyCodec[K any] interface {
+	EncodeKey(dst []byte, key K) []byte
+	DecodeKey(bs []byte) (K, error)
+}
+
+// ValueCodec will encode and decode values for a Typed tree
+type ValueCodec[V any] interface {
+	EncodeValue(dst []byte, val V) ([]byte, error)
+	DecodeValue(bs []byte) (V, error)
+}
+
+// Signed is a constraint for signed integer types
+type Signed interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+// Unsigned is a constraint for unsigned integer types
+type Unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// IntKeys encodes signed integers as 8 big-endian bytes with the sign bit flipped so negative
+// numbers sort before positive numbers
+type IntKeys[K Signed] struct{}
+
+// EncodeKey will append the encoded key to dst
+func (IntKeys[K]) EncodeKey(dst []byte, key K) []byte {
+	return binary.BigEndian.AppendUint64(dst, uint64(int64(key))^signBit)
+}
+
+// DecodeKey will decode an encoded key
+func (IntKeys[K]) DecodeKey(bs []byte) (key K, err error) {
+	if len(bs) != 8 {
+		err = ErrInvalidEncoding
+		return
+	}
+
+	return K(int64(binary.BigEndian.Uint64(bs) ^ signBit)), nil
+}
+
+// UintKeys encodes unsigned integers as 8 big-endian bytes
+type UintKeys[K Unsigned] struct{}
+
+// EncodeKey will append the encoded key to dst
+func (UintKeys[K]) EncodeKey(dst []byte, key K) []byte {
+	return binary.BigEndian.AppendUint64(dst, uint64(key))
+}
+
+// DecodeKey will decode an encoded key
+func (UintKeys[K]) DecodeKey(bs []byte) (key K, err error) {
+	if len(bs) != 8 {
+		err = ErrInvalidEncoding
+		return
+	}
+
+	return K(binary.BigEndian.Uint64(bs)), nil
+}
+
+// StringKeys encodes strings as their raw bytes
+type StringKeys[K ~string] struct{}
+
+// EncodeKey will append the encoded key to dst
+func (StringKeys[K]) EncodeKey(dst []byte, key K) []byte {
+	return append(dst, key...)
+}
+
+// DecodeKey will decode an encoded key
+func (StringKeys[K]) DecodeKey(bs []byte) (key K, err error) {
+	return K(bs), nil
+}
+
+// TimeKeys encodes times as sign-flipped unix seconds followed by nanoseconds, all big-endian
+// Note: Location is not retained, decoded times are in UTC
+type TimeKeys struct{}
+
+// EncodeKey will append the encoded key to dst
+func (TimeKeys) EncodeKey(dst []byte, key time.Time) []byte {
+	dst = binary.BigEndian.AppendUint64(dst, uint64(key.Unix())^signBit)
+	return binary.BigEndian.AppendUint32(dst, uint32(key.Nanosecond()))
+}
+
+// DecodeKey will decode an encoded key
+func (TimeKeys) DecodeKey(bs []byte) (key time.Time, err error) {
+	if len(bs) != timeKeySize {
+		err = ErrInvalidEncoding
+		return
+	}
+
+	sec := int64(binary.BigEndian.Uint64(bs) ^ signBit)
+	nsec := int64(binary.BigEndian.Uint32(bs[8:]))
+	return time.Unix(sec, nsec).UTC(), nil
+}
+
+// RawValues stores byteslice values as-is
+type RawValues struct{}
+
+// EncodeValue will append the encoded value to dst
+func (RawValues) EncodeValue(dst []byte, val []byte) ([]byte, error) {
+	return append(dst, val...), nil
+}
+
+// DecodeValue will decode an encoded value
+// Note: The value is copied so it remains valid after the tree is modified
+func (RawValues) DecodeValue(bs []byte) ([]byte, error) {
+	return append([]byte(nil), bs...), nil
+}
+
+// JSONValues stores values using encoding/json
+type JSONValues[V any] struct{}
+
+// EncodeValue will append the encoded value to dst
+func (JSONValues[V]) EncodeValue(dst []byte, val V) (bs []byte, err error) {
+	if bs, err = json.Marshal(val); err != nil {
+		return
+	}
+
+	return append(dst, bs...), nil
+}
+
+// DecodeValue will decode an encoded value
+func (JSONValues[V]) DecodeValue(bs []byte) (val V, err error) {
+	err = json.Unmarshal(bs, &val)
+	return
+}
+
+// GobValues stores values using encoding/gob
+// Note: Every value is encoded with it's own type information
+type GobValues[V any] struct{}
+
+// EncodeValue will append the encoded value to dst
+func (GobValues[V]) EncodeValue(dst []byte, val V) (bs []byte, err error) {
+	buf := bytes.NewBuffer(dst)
+	if err = gob.NewEncoder(buf).Encode(val); err != nil {
+		return
+	}
+
+	return buf.Bytes(), nil
+}
+
+// DecodeValue will decode an encoded value
+func (GobValues[V]) DecodeValue(bs []byte) (val V, err error) {
+	err = gob.NewDecoder(bytes.NewReader(bs)).Decode(&val)
+	return
+}
+
+// BinaryValues stores fixed-size values using encoding/binary in big-endian byte order
+type BinaryValues[V any] struct{}
+
+// EncodeValue will append the encoded value to dst
+func (BinaryValues[V]) EncodeValue(dst []byte, val V) ([]byte, error) {
+	return binary.Append(dst, binary.BigEndian, val)
+}
+
+// DecodeValue will decode an encoded value
+func (BinaryValues[V]) DecodeValue(bs []byte) (val V, err error) {
+	var n int
+	if n, err = binary.Decode(bs, binary.BigEndian, &val); err != nil {
+		return
+	}
+
+	if n != len(bs) {
+		err = ErrInvalidEncoding
+	}
+
+	return
+}
@@ -0,0 +1,88 @@
+package rbt
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+
+	"github.com/missionMeteora/toolkit/errors"
+)
+
+// ErrCompressorUnavailable is panicked with when a compressed value is read without a Compressor
+const ErrCompressorUnavailable = errors.Error("compressor unavailable")
+
+// minCompressLen is the smallest value (in bytes) which will be compressed
+const minCompressLen = 64
+
+// Compressor will compress and decompress values for a Tree
+type Compressor interface {
+	// Compress will append the compressed src to dst
+	Compress(dst, src []byte) []byte
+	// Decompress will append the decompressed src to dst
+	Decompress(dst, src []byte) ([]byte, error)
+}
+
+// NewFlate will return a new DEFLATE Compressor using the provided compression level
+// Note: Level is one of the compress/flate levels, flate.DefaultCompression is a good starting point
+func NewFlate(level int) (f *Flate, err error) {
+	var fl Flate
+	if fl.w, err = flate.NewWriter(nil, level); err != nil {
+		return
+	}
+
+	return &fl, nil
+}
+
+// Flate compresses values using DEFLATE (compress/flate)
+type Flate struct {
+	w *flate.Writer
+}
+
+// Compress will append the compressed src to dst
+func (f *Flate) Compress(dst, src []byte) []byte {
+	buf := bytes.NewBuffer(dst)
+	f.w.Reset(buf)
+	// Writes to a bytes.Buffer cannot fail
+	f.w.Write(src)
+	f.w.Close()
+	return buf.Bytes()
+}
+
+// Decompress will append the decompressed src to dst
+func (f *Flate) Decompress(dst, src []byte) (out []byte, err error) {
+	r := flate.NewReader(bytes.NewReader(src))
+	defer r.Close()
+
+	buf := bytes.NewBuffer(dst)
+	if _, err = io.Copy(buf, r); err != nil {
+		return
+	}
+
+	return buf.Bytes(), nil
+}
+
+// SetCompressor will set the Compressor used for the values of the tree and it's buckets, a nil
+// Compressor will store values raw. Values are only kept compressed when compression reduces their size.
+// Note: The Compressor is not persisted, it must be set again after re-opening a tree which holds
+// compressed values. Reading or updating a compressed value without a Compressor will panic with
+// ErrCompressorUnavailable and leave the value as is. Values written in place (Grow, Append, counters and
+// value writers) are stored raw.
+func (t *Tree) SetCompressor(c Compressor) {
+	t.compressor = c
+}
+
+// compress will return the value to store, compressed is true when the value has been compressed
+// Note: Compressed values reference a scratch buffer which is reused between calls
+func (t *Tree) compress(value []byte) (out []byte, compressed bool) {
+	if t.compressor == nil || len(value) < minCompressLen {
+		return value, false
+	}
+
+	t.cbuf = t.compressor.Compress(t.cbuf[:0], value)
+	if len(t.cbuf) >= len(value) {
+		// Compression did not help, store the value raw
+		return value, false
+	}
+
+	return t.cbuf, true
+}
@@ -0,0 +1,142 @@
+package rbt
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestCompressor(t *testing.T) {
+	c, err := NewFlate(flate.DefaultCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var vals [][]byte
+	for i := 0; i < 100; i++ {
+		val := fmt.Sprintf(`{"id":%d,"name":"user","tags":["a","b","c"],"bio":"%s"}`, i, bytes.Repeat([]byte("lorem ipsum "), 50))
+		vals = append(vals, []byte(val))
+	}
+
+	plain := New(1024)
+	w := New(1024)
+	// Values written before the compressor is set are stored raw
+	w.Put([]byte("raw"), vals[0])
+	w.SetCompressor(c)
+
+	for i, val := range vals {
+		key := []byte(fmt.Sprintf("user/%03d", i))
+		plain.Put(key, val)
+		w.Put(key, val)
+	}
+
+	if w.Size() >= plain.Size()/2 {
+		t.Fatalf("invalid size, expected less than %d and received %d", plain.Size()/2, w.Size())
+	}
+
+	for i, val := range vals {
+		if got := w.Get([]byte(fmt.Sprintf("user/%03d", i))); !bytes.Equal(got, val) {
+			t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", val, got)
+		}
+	}
+
+	if got := w.Get([]byte("raw")); !bytes.Equal(got, vals[0]) {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", vals[0], got)
+	}
+
+	w.ForEach(func(key, val []byte) (end bool) {
+		var i int
+		if _, err := fmt.Sscanf(string(key), "user/%03d", &i); err != nil {
+			i = 0
+		}
+
+		if !bytes.Equal(val, vals[i]) {
+			t.Fatalf("invalid value for \"%s\"", key)
+		}
+
+		return
+	})
+
+	// Small and incompressible values are stored raw
+	random := make([]byte, 1024)
+	rand.Read(random)
+	w.Put([]byte("small"), []byte("value"))
+	w.Put([]byte("random"), random)
+	for _, key := range []string{"small", "random"} {
+		if b := w.getBlock(w.seekBlock(w.getHeader().root, []byte(key))); b.flags&blockCompressed != 0 {
+			t.Fatalf("invalid block, expected \"%s\" to be stored raw", key)
+		}
+	}
+
+	if !w.CompareAndSwap([]byte("user/001"), vals[1], vals[2]) {
+		t.Fatal("invalid swap, expected the value to be swapped")
+	}
+
+	// Appending to a compressed value will store the value raw
+	w.Append([]byte("user/002"), []byte("!"))
+	if got := w.Get([]byte("user/002")); !bytes.Equal(got, append(append([]byte(nil), vals[2]...), '!')) {
+		t.Fatalf("invalid value, expected \"%s!\" and received \"%s\"", vals[2], got)
+	}
+
+	r, err := w.ValueReader([]byte("user/003"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := io.ReadAll(r); !bytes.Equal(got, vals[3]) {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", vals[3], got)
+	}
+}
+
+func TestCompressorUnavailable(t *testing.T) {
+	c, err := NewFlate(flate.DefaultCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := New(1024)
+	w.SetCompressor(c)
+	val := bytes.Repeat([]byte("compressible "), 35)
+	w.Put([]byte("compressed"), val)
+
+	// Values cannot be decoded once the compressor has been unset (as with a re-opened tree)
+	w.SetCompressor(nil)
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{"get", func() { w.Get([]byte("compressed")) }},
+		{"append", func() { w.Append([]byte("compressed"), []byte("X")) }},
+		{"grow", func() { w.Grow([]byte("compressed"), 1024) }},
+		{"incr", func() { w.Incr([]byte("compressed"), 1) }},
+		{"upsert", func() {
+			w.Upsert([]byte("compressed"), func(old []byte, exists bool) ([]byte, bool) { return []byte("X"), false })
+		}},
+		{"compare and delete", func() { w.CompareAndDelete([]byte("compressed"), nil) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if err := recover(); err != ErrCompressorUnavailable {
+					t.Fatalf("invalid panic, expected %v and received %v", ErrCompressorUnavailable, err)
+				}
+			}()
+
+			tt.fn()
+		})
+	}
+
+	if _, err = w.ValueReader([]byte("compressed")); err != ErrCompressorUnavailable {
+		t.Fatalf("invalid error, expected %v and received %v", ErrCompressorUnavailable, err)
+	}
+
+	// The stored value is left as is
+	w.SetCompressor(c)
+	if got := w.Get([]byte("compressed")); !bytes.Equal(got, val) {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", val, got)
+	}
+}
@@ -0,0 +1,81 @@
+package rbt
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// CounterSize is the size (in bytes) of counter values
+const CounterSize = 8
+
+// zeroCounter is used as the initial value for new counters
+var zeroCounter [CounterSize]byte
+
+// Incr will add delta to the counter stored for a key and return the new total. Counters are
+// stored as 8 byte big-endian values and are updated in place.
+// Note: Missing keys and values which are not 8 bytes are treated as a counter of zero. Counters are
+// re-sealed rather than updated in place while encryption is enabled.
+func (t *Tree) Incr(key []byte, delta int64) (n int64) {
+	val := t.getCounter(key)
+	n = int64(binary.BigEndian.Uint64(val)) + delta
+	binary.BigEndian.PutUint64(val, uint64(n))
+	t.setCounter(key, val)
+	return
+}
+
+// IncrFloat will add delta to the float counter stored for a key and return the new total. Float
+// counters are stored as the 8 byte big-endian IEEE 754 representation and are updated in place.
+// Note: Missing keys and values which are not 8 bytes are treated as a counter of zero. Counters are
+// re-sealed rather than updated in place while encryption is enabled.
+func (t *Tree) IncrFloat(key []byte, delta float64) (n float64) {
+	val := t.getCounter(key)
+	n = math.Float64frombits(binary.BigEndian.Uint64(val)) + delta
+	binary.BigEndian.PutUint64(val, math.Float64bits(n))
+	t.setCounter(key, val)
+	return
+}
+
+// getCounter will return the counter value for a key, creating a zeroed counter when needed
+// Note: While encryption is enabled, a decrypted copy of the counter is returned which must be stored
+// using setCounter
+func (t *Tree) getCounter(key []byte) (val []byte) {
+	if t.keys != nil {
+		val = make([]byte, CounterSize)
+		if offset := t.seekBlock(t.getHeader().root, key); offset != -1 {
+			if v := t.readValue(t.getBlock(offset)); len(v) == CounterSize {
+				copy(val, v)
+			}
+		}
+
+		return
+	}
+
+	offset := t.createBlock(key, CounterSize)
+	b := t.getBlock(offset)
+	// Encoded values are decoded before being read as a counter
+	if grew := t.decodeBlob(b, key); grew {
+		b = t.getBlock(offset)
+	}
+
+	if b.hasValue() && t.getValLen(b) == CounterSize {
+		return t.getValue(b)
+	}
+
+	created := !b.hasValue()
+	if grew := t.setBlob(b, key, zeroCounter[:]); grew {
+		b = t.getBlock(offset)
+	}
+
+	if created {
+		t.insertBalance(b)
+	}
+
+	return t.getValue(b)
+}
+
+// setCounter will store a counter value returned by getCounter, counters updated in place are left as is
+func (t *Tree) setCounter(key, val []byte) {
+	if t.keys != nil {
+		t.Put(key, val)
+	}
+}
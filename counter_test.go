@@ -0,0 +1,93 @@
+package rbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+)
+
+func TestIncr(t *testing.T) {
+	testIncr(t, New(1024))
+}
+
+func TestIncrEncrypted(t *testing.T) {
+	w := New(1024)
+	if err := w.SetKeyProvider(NewKeyring(1, bytes.Repeat([]byte("k"), 32))); err != nil {
+		t.Fatal(err)
+	}
+
+	testIncr(t, w)
+
+	// Counters remain sealed
+	b := w.getBlock(w.seekBlock(w.getHeader().root, []byte("counter")))
+	if b.flags&blockEncrypted == 0 || w.getValLen(b) == CounterSize {
+		t.Fatal("invalid counter, expected the counter to be encrypted")
+	}
+}
+
+func testIncr(t *testing.T, w *Tree) {
+	for i := 0; i < 100; i++ {
+		w.Incr([]byte("counter"), 2)
+	}
+
+	if n := w.Incr([]byte("counter"), -50); n != 150 {
+		t.Fatalf("invalid counter, expected %d and received %d", 150, n)
+	}
+
+	if n := int64(binary.BigEndian.Uint64(w.Get([]byte("counter")))); n != 150 {
+		t.Fatalf("invalid counter, expected %d and received %d", 150, n)
+	}
+
+	size := w.Size()
+	w.Incr([]byte("counter"), 1)
+	if w.Size() != size {
+		t.Fatalf("invalid size, expected %d and received %d", size, w.Size())
+	}
+
+	// Values which are not counters are treated as zero
+	w.Put([]byte("not a counter"), []byte("hello"))
+	if n := w.Incr([]byte("not a counter"), 7); n != 7 {
+		t.Fatalf("invalid counter, expected %d and received %d", 7, n)
+	}
+
+	if w.Len() != 2 {
+		t.Fatalf("invalid length, expected %d and received %d", 2, w.Len())
+	}
+}
+
+func TestIncrFloat(t *testing.T) {
+	w := New(1024)
+	for i := 0; i < 10; i++ {
+		w.IncrFloat([]byte("counter"), 0.5)
+	}
+
+	if n := w.IncrFloat([]byte("counter"), -1.25); n != 3.75 {
+		t.Fatalf("invalid counter, expected %v and received %v", 3.75, n)
+	}
+}
+
+func TestIncrMMAP(t *testing.T) {
+	var err error
+	if err = os.MkdirAll("./test_data", 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll("./test_data")
+
+	var tr *Tree
+	if tr, err = NewMMAP("./test_data", "mmap.db", 64); err != nil {
+		t.Fatal(err)
+	}
+
+	tr.Incr([]byte("requests"), 41)
+	tr.Close()
+
+	if tr, err = NewMMAP("./test_data", "mmap.db", 64); err != nil {
+		t.Fatal(err)
+	}
+	defer tr.Close()
+
+	if n := tr.Incr([]byte("requests"), 1); n != 42 {
+		t.Fatalf("invalid counter, expected %d and received %d", 42, n)
+	}
+}
@@ -13,7 +13,7 @@ type DebugBlock struct {
 
 // GetDebug will get the debug block tree
 func GetDebug(t *Tree) (b *DebugBlock) {
-	return getDebugBlock(t, t.t.root)
+	return getDebugBlock(t, t.getHeader().root)
 }
 
 func getDebugBlock(t *Tree, index int64) *DebugBlock {
@@ -26,9 +26,9 @@ func getDebugBlock(t *Tree, index int64) *DebugBlock {
 	b.Key = string(t.getKey(blk))
 	b.ChildType = blk.ct
 	b.Color = blk.c
-	b.Children[0] = getDebugBlock(t, blk.children[0])
-	b.Children[1] = getDebugBlock(t, blk.children[1])
-	if parent := t.getBlock(blk.parent); parent != nil {
+	b.Children[0] = getDebugBlock(t, blk.getChild(0))
+	b.Children[1] = getDebugBlock(t, blk.getChild(1))
+	if parent := t.getBlock(blk.getParent()); parent != nil {
 		b.Parent = string(t.getKey(parent))
 	}
 
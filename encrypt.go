@@ -0,0 +1,190 @@
+package rbt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/missionMeteora/toolkit/errors"
+)
+
+const (
+	// ErrKeyUnavailable is returned (or panicked with) when the key used to encrypt a value is not available
+	ErrKeyUnavailable = errors.Error("encryption key unavailable")
+	// ErrInvalidCiphertext is returned when an encrypted value is too short to be decrypted
+	ErrInvalidCiphertext = errors.Error("invalid ciphertext")
+	// ErrInvalidKey is returned when an encryption key is not 16, 24 or 32 bytes
+	ErrInvalidKey = errors.Error("invalid encryption key, keys must be 16, 24 or 32 bytes")
+	// ErrEncryptedInPlace is panicked with when a value is written in place while encryption is enabled
+	ErrEncryptedInPlace = errors.Error("values cannot be written in place while encryption is enabled")
+)
+
+const (
+	// SaltSize is the size (in bytes) of the per-file salt used to derive encryption keys
+	SaltSize = 16
+	// keyIDSize is the size (in bytes) of the key ID stored before each encrypted value
+	keyIDSize = 4
+	// nonceSize is the size (in bytes) of the AES-GCM nonces
+	nonceSize = 12
+)
+
+// KeyProvider provides the keys used to encrypt values. Each key is identified by an ID which is
+// stored alongside every value it encrypts, so keys can be rotated while older values remain readable.
+type KeyProvider interface {
+	// CurrentKey will return the ID and key to encrypt new values with
+	CurrentKey() (id uint32, key []byte)
+	// Key will return the key matching the provided ID, false is returned when the key is unknown
+	Key(id uint32) (key []byte, ok bool)
+}
+
+// NewKeyring will return a new Keyring using the provided key as the current key
+func NewKeyring(id uint32, key []byte) *Keyring {
+	var k Keyring
+	k.keys = make(map[uint32][]byte)
+	k.Rotate(id, key)
+	return &k
+}
+
+// Keyring is a simple in-memory KeyProvider
+type Keyring struct {
+	current uint32
+	keys    map[uint32][]byte
+}
+
+// Rotate will add a key and use it to encrypt new values, previous keys are kept for decryption
+func (k *Keyring) Rotate(id uint32, key []byte) {
+	k.keys[id] = append([]byte(nil), key...)
+	k.current = id
+}
+
+// CurrentKey will return the ID and key to encrypt new values with
+func (k *Keyring) CurrentKey() (id uint32, key []byte) {
+	return k.current, k.keys[k.current]
+}
+
+// Key will return the key matching the provided ID, false is returned when the key is unknown
+func (k *Keyring) Key(id uint32) (key []byte, ok bool) {
+	key, ok = k.keys[id]
+	return
+}
+
+// SetKeyProvider will set the KeyProvider used to encrypt the values of the tree and it's buckets using
+// AES-GCM, a nil KeyProvider will store values unencrypted. Encryption keys are derived from the provided
+// keys and the salt of the file, each value is bound to it's key so values cannot be swapped between keys.
+// ErrInvalidKey is returned when the current key is not a valid AES key.
+// Note: Keys are not encrypted as the tree is ordered by them. The KeyProvider is not persisted, it must be
+// set again after re-opening a tree. Values are never written in place while encryption is enabled, Append
+// and counters re-seal the entire value, value writers buffer in memory until closed and Grow will panic.
+// Storing a value will panic when the current key cannot be used, rather than storing the value unencrypted.
+// Reading or updating a value whose key is unavailable will panic with ErrKeyUnavailable and leave the value
+// as is.
+func (t *Tree) SetKeyProvider(kp KeyProvider) (err error) {
+	if kp != nil {
+		if _, key := kp.CurrentKey(); !isValidKey(key) {
+			return ErrInvalidKey
+		}
+	}
+
+	t.keys = kp
+	t.aeads = nil
+	return
+}
+
+// encrypt will return the value to store, encrypted is true when the value has been encrypted
+// Note: Encrypted values reference a scratch buffer which is reused between calls
+func (t *Tree) encrypt(key, value []byte) (out []byte, encrypted bool) {
+	if t.keys == nil {
+		return value, false
+	}
+
+	id, master := t.keys.CurrentKey()
+	aead, err := t.getAEAD(id, master)
+	if err != nil {
+		// Keys which cannot be used result in unencrypted values, refuse to store them instead
+		panic(err)
+	}
+
+	var nonce [nonceSize]byte
+	if _, err = rand.Read(nonce[:]); err != nil {
+		panic(err)
+	}
+
+	// Encrypted values are stored as the key ID, nonce and sealed value
+	out = binary.BigEndian.AppendUint32(t.ebuf[:0], id)
+	out = append(out, nonce[:]...)
+	t.ebuf = aead.Seal(out, nonce[:], value, key)
+	return t.ebuf, true
+}
+
+// decrypt will return the decrypted value, the value is decrypted into a new slice
+func (t *Tree) decrypt(key, value []byte) (out []byte, err error) {
+	if t.keys == nil || len(value) < keyIDSize {
+		return nil, ErrKeyUnavailable
+	}
+
+	id := binary.BigEndian.Uint32(value)
+	master, ok := t.keys.Key(id)
+	if !ok {
+		return nil, ErrKeyUnavailable
+	}
+
+	var aead cipher.AEAD
+	if aead, err = t.getAEAD(id, master); err != nil {
+		return
+	}
+
+	value = value[keyIDSize:]
+	if len(value) < nonceSize {
+		return nil, ErrInvalidCiphertext
+	}
+
+	return aead.Open(nil, value[:nonceSize], value[nonceSize:], key)
+}
+
+// getAEAD will return the cipher for a key ID, ciphers are derived once and cached
+func (t *Tree) getAEAD(id uint32, master []byte) (aead cipher.AEAD, err error) {
+	if aead = t.aeads[id]; aead != nil {
+		return
+	}
+
+	if master == nil {
+		return nil, ErrKeyUnavailable
+	}
+
+	if !isValidKey(master) {
+		return nil, ErrInvalidKey
+	}
+
+	// Derive a file specific key using HMAC-SHA256 keyed by the salt (the extract step of HKDF)
+	mac := hmac.New(sha256.New, t.t.salt[:])
+	mac.Write(master)
+
+	var block cipher.Block
+	if block, err = aes.NewCipher(mac.Sum(nil)); err != nil {
+		return
+	}
+
+	if aead, err = cipher.NewGCM(block); err != nil {
+		return
+	}
+
+	if t.aeads == nil {
+		t.aeads = make(map[uint32]cipher.AEAD)
+	}
+
+	t.aeads[id] = aead
+	return
+}
+
+// isValidKey will return whether or not a key is a valid AES-128, AES-192 or AES-256 key
+func isValidKey(key []byte) bool {
+	switch len(key) {
+	case 16, 24, 32:
+		return true
+	}
+
+	return false
+}
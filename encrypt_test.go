@@ -0,0 +1,196 @@
+package rbt
+
+import (
+	"bytes"
+	"compress/flate"
+	"os"
+	"testing"
+)
+
+func TestEncryption(t *testing.T) {
+	var err error
+	if err = os.MkdirAll("./test_data", 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll("./test_data")
+
+	var w *Tree
+	if w, err = NewMMAP("./test_data", "encrypted.db", 1024); err != nil {
+		t.Fatal(err)
+	}
+
+	kr := NewKeyring(1, bytes.Repeat([]byte("k"), 32))
+	w.SetKeyProvider(kr)
+
+	secret := []byte("customer ssn 123-45-6789")
+	w.Put([]byte("customer"), secret)
+	if val := w.Get([]byte("customer")); !bytes.Equal(val, secret) {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", secret, val)
+	}
+
+	if bytes.Contains(w.bs, secret) {
+		t.Fatal("invalid storage, expected the value to be encrypted")
+	}
+
+	// Rotated keys are used for new values while older values remain readable
+	kr.Rotate(2, bytes.Repeat([]byte("r"), 32))
+	w.Put([]byte("rotated"), []byte("rotated value"))
+	b := w.getBlock(w.seekBlock(w.getHeader().root, []byte("rotated")))
+	if id := w.getValue(b)[3]; id != 2 {
+		t.Fatalf("invalid key ID, expected %d and received %d", 2, id)
+	}
+
+	// Encryption can be combined with compression
+	c, err := NewFlate(flate.DefaultCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compressible := bytes.Repeat([]byte("compressible "), 100)
+	w.SetCompressor(c)
+	w.Put([]byte("compressed"), compressible)
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Re-open the file, the salt is read from the header
+	if w, err = NewMMAP("./test_data", "encrypted.db", 1024); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	func() {
+		defer func() {
+			if err := recover(); err != ErrKeyUnavailable {
+				t.Fatalf("invalid panic, expected %v without a key provider and received %v", ErrKeyUnavailable, err)
+			}
+		}()
+
+		w.Get([]byte("customer"))
+	}()
+
+	w.SetKeyProvider(kr)
+	w.SetCompressor(c)
+	for key, expected := range map[string][]byte{
+		"customer":   secret,
+		"rotated":    []byte("rotated value"),
+		"compressed": compressible,
+	} {
+		if val := w.Get([]byte(key)); !bytes.Equal(val, expected) {
+			t.Fatalf("invalid value for \"%s\", expected \"%s\" and received \"%s\"", key, expected, val)
+		}
+	}
+
+	// Values are bound to their keys
+	other := w.getBlock(w.seekBlock(w.getHeader().root, []byte("customer")))
+	if _, err = w.decrypt([]byte("rotated"), w.getValue(other)); err == nil {
+		t.Fatal("invalid error, expected an error when decrypting a value with the wrong key")
+	}
+}
+
+func TestEncryptionInPlace(t *testing.T) {
+	w := New(1024)
+	if err := w.SetKeyProvider(NewKeyring(1, []byte("short"))); err != ErrInvalidKey {
+		t.Fatalf("invalid error, expected %v and received %v", ErrInvalidKey, err)
+	}
+
+	if err := w.SetKeyProvider(NewKeyring(1, bytes.Repeat([]byte("k"), 16))); err != nil {
+		t.Fatal(err)
+	}
+
+	// Appended values are re-sealed
+	w.Append([]byte("appended"), []byte("secret"))
+	w.Append([]byte("appended"), []byte("secret"))
+	w.Append([]byte("appended"), []byte("more"))
+	if val := string(w.Get([]byte("appended"))); val != "secretsecretmore" {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "secretsecretmore", val)
+	}
+
+	// Streamed values are buffered until they can be sealed
+	vw := w.ValueWriter([]byte("streamed"), 4)
+	vw.Write([]byte("streamed "))
+	vw.Write([]byte("secret"))
+	if err := vw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if val := string(w.Get([]byte("streamed"))); val != "streamed secret" {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "streamed secret", val)
+	}
+
+	// Buckets can be created while encryption is enabled, their values are sealed as well
+	b, err := w.CreateBucket([]byte("bucket"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b.Append([]byte("nested"), []byte("secret"))
+	if val := string(b.Get([]byte("nested"))); val != "secret" {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "secret", val)
+	}
+
+	if bytes.Contains(w.bs, []byte("secret")) {
+		t.Fatal("invalid storage, expected values to be encrypted")
+	}
+
+	defer func() {
+		if err := recover(); err != ErrEncryptedInPlace {
+			t.Fatalf("invalid panic, expected %v and received %v", ErrEncryptedInPlace, err)
+		}
+	}()
+
+	w.Grow([]byte("grown"), 16)
+}
+
+func TestEncryptionKeyUnavailable(t *testing.T) {
+	kr := NewKeyring(1, bytes.Repeat([]byte("k"), 32))
+	w := New(1024)
+	w.SetKeyProvider(kr)
+	w.Put([]byte("secret"), []byte("customer data"))
+	w.Put([]byte("counter"), make([]byte, CounterSize))
+
+	// Providers which do not hold the key of a value cannot decrypt it
+	other := NewKeyring(2, bytes.Repeat([]byte("o"), 32))
+	tests := []struct {
+		name string
+		kp   KeyProvider
+		fn   func()
+	}{
+		{"get", nil, func() { w.Get([]byte("secret")) }},
+		{"append", nil, func() { w.Append([]byte("secret"), []byte("!")) }},
+		{"grow", nil, func() { w.Grow([]byte("secret"), 64) }},
+		{"incr", nil, func() { w.Incr([]byte("counter"), 1) }},
+		{"get other", other, func() { w.Get([]byte("secret")) }},
+		{"append other", other, func() { w.Append([]byte("secret"), []byte("!")) }},
+		{"incr other", other, func() { w.Incr([]byte("counter"), 1) }},
+		{"for each other", other, func() { w.ForEach(func(key, val []byte) bool { return false }) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w.SetKeyProvider(tt.kp)
+			defer func() {
+				if err := recover(); err != ErrKeyUnavailable {
+					t.Fatalf("invalid panic, expected %v and received %v", ErrKeyUnavailable, err)
+				}
+			}()
+
+			tt.fn()
+		})
+	}
+
+	w.SetKeyProvider(nil)
+	if _, err := w.ValueReader([]byte("secret")); err != ErrKeyUnavailable {
+		t.Fatalf("invalid error, expected %v and received %v", ErrKeyUnavailable, err)
+	}
+
+	// The stored values are left as is
+	w.SetKeyProvider(kr)
+	if val := string(w.Get([]byte("secret"))); val != "customer data" {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "customer data", val)
+	}
+
+	if n := w.Incr([]byte("counter"), 1); n != 1 {
+		t.Fatalf("invalid counter, expected %d and received %d", 1, n)
+	}
+}
@@ -4,16 +4,59 @@ type color uint8
 
 type childType uint8
 
+type blockFlag uint8
+
+// Format represents the encoding of the offsets and lengths held by blocks
+type Format uint8
+
 type trunk struct {
+	// Identifies the file as a tree and the version of the layout it was written with
+	magic   [4]byte
+	version uint32
+	// Format of the blocks, chosen when the tree is created
+	format Format
+
+	header
+	tail int64
+	cap  int64
+
+	// Heads of the free lists, indexed by list and size class
+	free [freeLists][freeClasses]int64
+
+	// Salt used when deriving encryption keys
+	salt [SaltSize]byte
+}
+
+// legacyTrunk is the trunk written before layout versions were recorded
+type legacyTrunk struct {
 	root int64
 	cnt  int64
 	tail int64
 	cap  int64
 }
 
+// header holds the root reference, count and Bloom filter reference for a tree
+type header struct {
+	root  int64
+	cnt   int64
+	bloom int64
+}
+
 // ForEachFn is used when calling ForEach from a Tree
 type ForEachFn func(key, val []byte) (end bool)
 
+// ForEachKeyFn is used when calling ForEachKey from a Tree
+type ForEachKeyFn func(key []byte) (end bool)
+
+// TypedForEachFn is used when calling ForEach or Range from a Typed tree
+type TypedForEachFn[K, V any] func(key K, val V) (end bool)
+
+// UpsertFn is used when calling Upsert from a Tree
+type UpsertFn func(old []byte, exists bool) (new []byte, del bool)
+
+// DeleteFn is used when calling DeleteFunc from a Tree
+type DeleteFn func(key, val []byte) (del bool)
+
 // GrowFn is used when calling grow internally
 type GrowFn func(sz int64) (bs []byte)
 
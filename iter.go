@@ -0,0 +1,151 @@
+package rbt
+
+import (
+	"bytes"
+	"iter"
+)
+
+// All will return an iterator over each tree item in ascending order
+// Note: Keys and values are only valid until the tree is modified. Deleting the current item
+// during iteration is safe.
+func (t *Tree) All() iter.Seq2[[]byte, []byte] {
+	return t.Range(nil, nil)
+}
+
+// Keys will return an iterator over each tree key in ascending order
+// Note: Values are not accessed
+func (t *Tree) Keys() iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		for offset := t.seekStart(nil); offset != -1; {
+			key := t.getKey(t.getBlock(offset))
+			// Acquire the next offset before yielding in case the current item is deleted
+			offset = t.getNext(offset)
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// Values will return an iterator over each tree value in ascending key order
+func (t *Tree) Values() iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		for _, val := range t.Range(nil, nil) {
+			if !yield(val) {
+				return
+			}
+		}
+	}
+}
+
+// Backward will return an iterator over each tree item in descending order
+func (t *Tree) Backward() iter.Seq2[[]byte, []byte] {
+	return t.RangeBackward(nil, nil)
+}
+
+// Range will return an iterator over each tree item within the range of [start, end) in ascending order
+// Note: A nil start or end will leave that side of the range unbounded
+func (t *Tree) Range(start, end []byte) iter.Seq2[[]byte, []byte] {
+	return func(yield func([]byte, []byte) bool) {
+		for offset := t.seekStart(start); offset != -1; {
+			b := t.getBlock(offset)
+			key := t.getKey(b)
+			if end != nil && bytes.Compare(key, end) != -1 {
+				return
+			}
+
+			// Acquire the next offset before yielding in case the current item is deleted
+			offset = t.getNext(offset)
+			if !yield(key, t.readValue(b)) {
+				return
+			}
+		}
+	}
+}
+
+// RangeBackward will return an iterator over each tree item within the range of [start, end) in descending order
+// Note: A nil start or end will leave that side of the range unbounded
+func (t *Tree) RangeBackward(start, end []byte) iter.Seq2[[]byte, []byte] {
+	return func(yield func([]byte, []byte) bool) {
+		for offset := t.seekEnd(end); offset != -1; {
+			b := t.getBlock(offset)
+			key := t.getKey(b)
+			if start != nil && bytes.Compare(key, start) == -1 {
+				return
+			}
+
+			// Acquire the previous offset before yielding in case the current item is deleted
+			offset = t.getPrev(offset)
+			if !yield(key, t.readValue(b)) {
+				return
+			}
+		}
+	}
+}
+
+// getTail will get the very last item starting from a given node
+// Note: If called from root, will return the last item in the tree
+func (t *Tree) getTail(startOffset int64) (offset int64) {
+	offset = startOffset
+	for depth := 0; offset != -1; depth++ {
+		checkDepth(depth)
+		child := t.getBlock(offset).getChild(1)
+		if child == -1 {
+			return
+		}
+
+		offset = child
+	}
+
+	return
+}
+
+// getPrev will get the item directly preceding a given node
+func (t *Tree) getPrev(startOffset int64) (offset int64) {
+	b := t.getBlock(startOffset)
+	if child := b.getChild(0); child != -1 {
+		return t.getTail(child)
+	}
+
+	// Walk up until we arrive from a right child
+	for depth := 0; b.ct == childLeft; depth++ {
+		checkDepth(depth)
+		b = t.getBlock(b.getParent())
+	}
+
+	if b.ct == childRoot {
+		// We've walked up from the left-most item, nothing precedes
+		return -1
+	}
+
+	return b.getParent()
+}
+
+// seekEnd will return the last Block whose key is less than end
+// Note: A nil end will return the very last Block
+func (t *Tree) seekEnd(end []byte) (offset int64) {
+	if end == nil {
+		return t.getTail(t.getHeader().root)
+	}
+
+	return t.seekLower(t.getHeader().root, end)
+}
+
+// seekLower will return the last Block whose key is less than the provided key
+func (t *Tree) seekLower(startOffset int64, key []byte) (offset int64) {
+	offset = -1
+	for depth, cur := 0, startOffset; cur != -1; depth++ {
+		checkDepth(depth)
+		block := t.getBlock(cur)
+		if t.compareKey(key, block) != 1 {
+			cur = block.getChild(0)
+			continue
+		}
+
+		// This block is the lower unless a larger match exists within the right branch
+		offset = cur
+		cur = block.getChild(1)
+	}
+
+	return
+}
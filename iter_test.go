@@ -0,0 +1,132 @@
+package rbt
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestIterators(t *testing.T) {
+	w := New(1024)
+	var expected []string
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("%03d", i*2)
+		w.Put([]byte(key), []byte(key))
+		expected = append(expected, key)
+	}
+
+	var keys []string
+	for key, val := range w.All() {
+		if string(key) != string(val) {
+			t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", string(key), string(val))
+		}
+
+		keys = append(keys, string(key))
+	}
+
+	if !slices.Equal(keys, expected) {
+		t.Fatalf("invalid keys, expected %v and received %v", expected, keys)
+	}
+
+	keys = keys[:0]
+	for key := range w.Keys() {
+		if keys = append(keys, string(key)); len(keys) == 10 {
+			break
+		}
+	}
+
+	if !slices.Equal(keys, expected[:10]) {
+		t.Fatalf("invalid keys, expected %v and received %v", expected[:10], keys)
+	}
+
+	keys = keys[:0]
+	for key := range w.Backward() {
+		keys = append(keys, string(key))
+	}
+
+	slices.Reverse(keys)
+	if !slices.Equal(keys, expected) {
+		t.Fatalf("invalid keys, expected %v and received %v", expected, keys)
+	}
+
+	if n := len(slices.Collect(w.Values())); n != 100 {
+		t.Fatalf("invalid number of values, expected %d and received %d", 100, n)
+	}
+}
+
+func TestIteratorRanges(t *testing.T) {
+	w := New(1024)
+	for i := 0; i < 100; i++ {
+		key := []byte(fmt.Sprintf("%03d", i*2))
+		w.Put(key, key)
+	}
+
+	tests := []struct {
+		start, end string
+		asc, desc  []string
+	}{
+		{"010", "016", []string{"010", "012", "014"}, []string{"014", "012", "010"}},
+		{"009", "015", []string{"010", "012", "014"}, []string{"014", "012", "010"}},
+		{"", "004", []string{"000", "002"}, []string{"002", "000"}},
+		{"194", "", []string{"194", "196", "198"}, []string{"198", "196", "194"}},
+		{"050", "050", nil, nil},
+		{"999", "", nil, nil},
+	}
+
+	for _, test := range tests {
+		var start, end []byte
+		if test.start != "" {
+			start = []byte(test.start)
+		}
+
+		if test.end != "" {
+			end = []byte(test.end)
+		}
+
+		var asc, desc []string
+		for key := range w.Range(start, end) {
+			asc = append(asc, string(key))
+		}
+
+		for key := range w.RangeBackward(start, end) {
+			desc = append(desc, string(key))
+		}
+
+		if !slices.Equal(asc, test.asc) {
+			t.Fatalf("invalid keys for [%s, %s), expected %v and received %v", test.start, test.end, test.asc, asc)
+		}
+
+		if !slices.Equal(desc, test.desc) {
+			t.Fatalf("invalid keys for [%s, %s), expected %v and received %v", test.start, test.end, test.desc, desc)
+		}
+	}
+}
+
+func TestIteratorDelete(t *testing.T) {
+	w := New(1024)
+	for i := 0; i < 1000; i++ {
+		key := []byte(fmt.Sprintf("%04d", i))
+		w.Put(key, key)
+	}
+
+	for key := range w.All() {
+		w.Delete(key)
+	}
+
+	if w.Len() != 0 {
+		t.Fatalf("invalid length, expected %d and received %d", 0, w.Len())
+	}
+
+	for i := 0; i < 1000; i++ {
+		key := []byte(fmt.Sprintf("%04d", i))
+		w.Put(key, key)
+	}
+
+	for key := range w.Backward() {
+		w.Delete(key)
+	}
+
+	if w.Len() != 0 {
+		t.Fatalf("invalid length, expected %d and received %d", 0, w.Len())
+	}
+}
@@ -0,0 +1,180 @@
+package rbt
+
+// split will split a tree into two separate trees. The left tree will contain all the keys which
+// are less than the provided key and the right tree will contain the remaining keys.
+// Note: A nil key will result in the entire tree being placed on the right side
+func (t *Tree) split(root int64, key []byte, depth int) (left, right int64) {
+	if root == -1 {
+		return -1, -1
+	}
+
+	checkDepth(depth)
+	b := t.getBlock(root)
+	lc := t.detach(b.getChild(0))
+	rc := t.detach(b.getChild(1))
+
+	if t.compareKey(key, b) != 1 {
+		// Block belongs on the right side, continue splitting down the left branch
+		var rest int64
+		left, rest = t.split(lc, key, depth+1)
+		right = t.join(rest, b, rc)
+		return
+	}
+
+	// Block belongs on the left side, continue splitting down the right branch
+	var rest int64
+	rest, right = t.split(rc, key, depth+1)
+	left = t.join(lc, b, rest)
+	return
+}
+
+// merge will combine two trees, every key within the left tree must be less than the keys within the right
+func (t *Tree) merge(left, right int64) (root int64) {
+	switch {
+	case left == -1:
+		return right
+	case right == -1:
+		return left
+	}
+
+	rest, last := t.splitLast(left, 0)
+	return t.join(rest, last, right)
+}
+
+// splitLast will remove the very last item from a tree, the remaining tree and the removed block are returned
+func (t *Tree) splitLast(root int64, depth int) (rest int64, last *Block) {
+	checkDepth(depth)
+	b := t.getBlock(root)
+	lc := t.detach(b.getChild(0))
+	if b.getChild(1) == -1 {
+		return lc, b
+	}
+
+	rest, last = t.splitLast(t.detach(b.getChild(1)), depth+1)
+	rest = t.join(lc, b, rest)
+	return
+}
+
+// join will combine two trees using a middle block. Every key within the left tree must be less than
+// the middle key and every key within the right tree must be greater than the middle key.
+// Note: Both trees must have black roots
+func (t *Tree) join(left int64, mid *Block, right int64) (root int64) {
+	lbl := t.getBlackLevel(left)
+	rbl := t.getBlackLevel(right)
+
+	switch {
+	case lbl > rbl:
+		// Left tree is taller, walk down the right spine of the left tree until the black levels match
+		parent := t.getBlock(t.seekBlackLevel(left, rbl, 1))
+		t.setChildren(mid, parent.getChild(1), right)
+		t.setChild(parent, mid, childRight)
+
+	case lbl < rbl:
+		// Right tree is taller, walk down the left spine of the right tree until the black levels match
+		parent := t.getBlock(t.seekBlackLevel(right, lbl, 0))
+		t.setChildren(mid, left, parent.getChild(0))
+		t.setChild(parent, mid, childLeft)
+
+	default:
+		// Black levels match, middle block becomes the new root
+		t.setChildren(mid, left, right)
+		mid.c = colorBlack
+		mid.ct = childRoot
+		mid.setParent(-1)
+		return mid.getOffset()
+	}
+
+	// Middle block has been inserted as red, balance the same as we would an insert
+	mid.c = colorRed
+	t.balance(mid)
+
+	for depth := 0; mid.ct != childRoot; depth++ {
+		checkDepth(depth)
+		mid = t.getBlock(mid.getParent())
+	}
+
+	return mid.getOffset()
+}
+
+// detach will detach a block from it's parent and paint it black so it can be treated as a root
+func (t *Tree) detach(offset int64) int64 {
+	if b := t.getBlock(offset); b != nil {
+		b.c = colorBlack
+		b.ct = childRoot
+		b.setParent(-1)
+	}
+
+	return offset
+}
+
+// getBlackLevel will return the number of black blocks between the provided block and it's leaves
+func (t *Tree) getBlackLevel(offset int64) (level int) {
+	for depth, b := 0, t.getBlock(offset); b != nil; depth, b = depth+1, t.getBlock(b.getChild(0)) {
+		checkDepth(depth)
+		if b.c == colorBlack {
+			level++
+		}
+	}
+
+	return
+}
+
+// seekBlackLevel will walk down one side of a tree and return the parent of the first black
+// position with the provided black level. Side 0 walks the left spine and side 1 walks the right spine.
+// Note: The provided black level must be lower than the black level of the root
+func (t *Tree) seekBlackLevel(root int64, level, side int) (parent int64) {
+	current := t.getBlackLevel(root)
+	b := t.getBlock(root)
+	for depth := 0; ; depth++ {
+		checkDepth(depth)
+		if b.c == colorBlack {
+			current--
+		}
+
+		child := t.getBlock(b.getChild(side))
+		if current == level && isBlack(child) {
+			return b.getOffset()
+		}
+
+		b = child
+	}
+}
+
+// setChildren will set the left and right children of a block
+func (t *Tree) setChildren(b *Block, left, right int64) {
+	b.setChild(0, -1)
+	b.setChild(1, -1)
+	if child := t.getBlock(left); child != nil {
+		t.setChild(b, child, childLeft)
+	}
+
+	if child := t.getBlock(right); child != nil {
+		t.setChild(b, child, childRight)
+	}
+}
+
+// setChild will set a child for a block on the side represented by the child type
+func (t *Tree) setChild(b, child *Block, ct childType) {
+	if ct == childLeft {
+		b.setChild(0, child.getOffset())
+	} else {
+		b.setChild(1, child.getOffset())
+	}
+
+	child.setParent(b.getOffset())
+	child.ct = ct
+}
+
+// freeTree will release every block and blob within a tree, the number of released blocks is returned
+func (t *Tree) freeTree(root int64, depth int) (n int) {
+	b := t.getBlock(root)
+	if b == nil {
+		return
+	}
+
+	checkDepth(depth)
+	n += t.freeTree(b.getChild(0), depth+1)
+	n += t.freeTree(b.getChild(1), depth+1)
+	t.freeBlock(b)
+	return n + 1
+}
@@ -0,0 +1,267 @@
+// Package keys encodes tuples of values into byteslices whose bytes.Compare order matches the
+// lexicographic order of the tuples. Values of differing types are ordered by type in the following
+// order: []byte, string, bool, int64, uint64, float64, time.Time.
+package keys
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/missionMeteora/toolkit/errors"
+)
+
+const (
+	// ErrUnsupportedType is returned when encoding a value whose type is not supported
+	ErrUnsupportedType = errors.Error("unsupported type")
+	// ErrInvalidEncoding is returned when decoding bytes which were not produced by this package
+	ErrInvalidEncoding = errors.Error("invalid encoding")
+)
+
+// Type tags, the order of the tags determines the order of values with differing types
+// Note: Tags must remain below escapeByte so terminated byteslices sort before longer byteslices
+const (
+	tagBytes byte = iota + 1
+	tagString
+	tagBool
+	tagInt
+	tagUint
+	tagFloat
+	tagTime
+)
+
+const (
+	// terminator marks the end of an encoded byteslice or string
+	terminator byte = 0x00
+	// escapeByte follows every zero byte within an encoded byteslice or string
+	escapeByte byte = 0xFF
+
+	signBit = 1 << 63
+)
+
+// Encode will encode the provided values as a key
+// Note: int, int8, int16 and int32 values are encoded as int64 and uint, uint8, uint16 and uint32 values
+// are encoded as uint64
+func Encode(vals ...any) (key []byte, err error) {
+	return Append(nil, vals...)
+}
+
+// Append will append the provided values to dst as a key
+func Append(dst []byte, vals ...any) (key []byte, err error) {
+	key = dst
+	for _, v := range vals {
+		switch val := v.(type) {
+		case []byte:
+			key = AppendBytes(key, val)
+		case string:
+			key = AppendString(key, val)
+		case bool:
+			key = AppendBool(key, val)
+		case int:
+			key = AppendInt(key, int64(val))
+		case int8:
+			key = AppendInt(key, int64(val))
+		case int16:
+			key = AppendInt(key, int64(val))
+		case int32:
+			key = AppendInt(key, int64(val))
+		case int64:
+			key = AppendInt(key, val)
+		case uint:
+			key = AppendUint(key, uint64(val))
+		case uint8:
+			key = AppendUint(key, uint64(val))
+		case uint16:
+			key = AppendUint(key, uint64(val))
+		case uint32:
+			key = AppendUint(key, uint64(val))
+		case uint64:
+			key = AppendUint(key, val)
+		case float32:
+			key = AppendFloat(key, float64(val))
+		case float64:
+			key = AppendFloat(key, val)
+		case time.Time:
+			key = AppendTime(key, val)
+		default:
+			return dst, ErrUnsupportedType
+		}
+	}
+
+	return
+}
+
+// AppendBytes will append an encoded byteslice to dst
+func AppendBytes(dst, val []byte) []byte {
+	dst = append(dst, tagBytes)
+	return appendEscaped(dst, val)
+}
+
+// AppendString will append an encoded string to dst
+func AppendString(dst []byte, val string) []byte {
+	dst = append(dst, tagString)
+	return appendEscaped(dst, val)
+}
+
+// AppendBool will append an encoded bool to dst, false sorts before true
+func AppendBool(dst []byte, val bool) []byte {
+	if val {
+		return append(dst, tagBool, 1)
+	}
+
+	return append(dst, tagBool, 0)
+}
+
+// AppendInt will append an encoded int64 to dst, negative numbers sort before positive numbers
+func AppendInt(dst []byte, val int64) []byte {
+	dst = append(dst, tagInt)
+	return binary.BigEndian.AppendUint64(dst, uint64(val)^signBit)
+}
+
+// AppendUint will append an encoded uint64 to dst
+func AppendUint(dst []byte, val uint64) []byte {
+	dst = append(dst, tagUint)
+	return binary.BigEndian.AppendUint64(dst, val)
+}
+
+// AppendFloat will append an encoded float64 to dst
+// Note: -0 sorts before 0, NaN values sort after +Inf (or before -Inf when their sign bit is set)
+func AppendFloat(dst []byte, val float64) []byte {
+	bits := math.Float64bits(val)
+	if bits&signBit != 0 {
+		// Negative numbers have all bits flipped so larger magnitudes sort first
+		bits = ^bits
+	} else {
+		bits |= signBit
+	}
+
+	dst = append(dst, tagFloat)
+	return binary.BigEndian.AppendUint64(dst, bits)
+}
+
+// AppendTime will append an encoded time to dst
+// Note: Location is not retained, decoded times are in UTC
+func AppendTime(dst []byte, val time.Time) []byte {
+	dst = append(dst, tagTime)
+	dst = binary.BigEndian.AppendUint64(dst, uint64(val.Unix())^signBit)
+	return binary.BigEndian.AppendUint32(dst, uint32(val.Nanosecond()))
+}
+
+// Decode will decode a key into it's values. Values are returned as one of the following types:
+// []byte, string, bool, int64, uint64, float64 or time.Time
+func Decode(key []byte) (vals []any, err error) {
+	var val any
+	for len(key) > 0 {
+		if val, key, err = decodeValue(key); err != nil {
+			return nil, err
+		}
+
+		vals = append(vals, val)
+	}
+
+	return
+}
+
+// PrefixEnd will return the first key which sorts after every key beginning with the provided prefix.
+// The returned key can be used as the exclusive end of a range, nil is returned if no such key exists
+func PrefixEnd(prefix []byte) (end []byte) {
+	end = append(end, prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i]++; end[i] != 0 {
+			return end[:i+1]
+		}
+	}
+
+	return nil
+}
+
+func decodeValue(key []byte) (val any, rest []byte, err error) {
+	tag := key[0]
+	key = key[1:]
+
+	switch tag {
+	case tagBytes:
+		return decodeEscaped(key)
+	case tagString:
+		var bs []byte
+		if bs, rest, err = decodeEscaped(key); err != nil {
+			return
+		}
+
+		return string(bs), rest, nil
+	case tagBool:
+		if len(key) < 1 {
+			break
+		}
+
+		return key[0] == 1, key[1:], nil
+	case tagInt:
+		if len(key) < 8 {
+			break
+		}
+
+		return int64(binary.BigEndian.Uint64(key) ^ signBit), key[8:], nil
+	case tagUint:
+		if len(key) < 8 {
+			break
+		}
+
+		return binary.BigEndian.Uint64(key), key[8:], nil
+	case tagFloat:
+		if len(key) < 8 {
+			break
+		}
+
+		bits := binary.BigEndian.Uint64(key)
+		if bits&signBit != 0 {
+			bits &^= signBit
+		} else {
+			bits = ^bits
+		}
+
+		return math.Float64frombits(bits), key[8:], nil
+	case tagTime:
+		if len(key) < 12 {
+			break
+		}
+
+		sec := int64(binary.BigEndian.Uint64(key) ^ signBit)
+		nsec := int64(binary.BigEndian.Uint32(key[8:]))
+		return time.Unix(sec, nsec).UTC(), key[12:], nil
+	}
+
+	err = ErrInvalidEncoding
+	return
+}
+
+// appendEscaped will append a value with every zero byte escaped, followed by a terminator
+func appendEscaped[T []byte | string](dst []byte, val T) []byte {
+	for i := 0; i < len(val); i++ {
+		if dst = append(dst, val[i]); val[i] == terminator {
+			dst = append(dst, escapeByte)
+		}
+	}
+
+	return append(dst, terminator)
+}
+
+func decodeEscaped(key []byte) (val []byte, rest []byte, err error) {
+	val = []byte{}
+	for i := 0; i < len(key); i++ {
+		if key[i] != terminator {
+			val = append(val, key[i])
+			continue
+		}
+
+		if i+1 < len(key) && key[i+1] == escapeByte {
+			// Escaped zero byte
+			val = append(val, terminator)
+			i++
+			continue
+		}
+
+		return val, key[i+1:], nil
+	}
+
+	return nil, nil, ErrInvalidEncoding
+}
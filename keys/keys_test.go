@@ -0,0 +1,198 @@
+package keys
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	now := time.Unix(1700000000, 123456789).UTC()
+	vals := []any{
+		[]byte("a\x00b"), "hello", "", true, false, int64(-42), int64(math.MaxInt64), uint64(42),
+		float64(-1.5), math.Inf(1), now, time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	key, err := Encode(vals...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := Decode(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(vals, decoded) {
+		t.Fatalf("invalid values, expected %v and received %v", vals, decoded)
+	}
+
+	if _, err = Encode(struct{}{}); err != ErrUnsupportedType {
+		t.Fatalf("invalid error, expected %v and received %v", ErrUnsupportedType, err)
+	}
+
+	if _, err = Decode(key[:len(key)-1]); err != ErrInvalidEncoding {
+		t.Fatalf("invalid error, expected %v and received %v", ErrInvalidEncoding, err)
+	}
+}
+
+func TestOrder(t *testing.T) {
+	tuples := make([][]any, 0, 2000)
+	for i := 0; i < cap(tuples); i++ {
+		tuple := make([]any, 1+rand.Intn(3))
+		for j := range tuple {
+			tuple[j] = testRandomValue()
+		}
+
+		tuples = append(tuples, tuple)
+	}
+
+	sort.Slice(tuples, func(i, j int) bool {
+		return testCompareTuples(tuples[i], tuples[j]) == -1
+	})
+
+	var last []byte
+	for i, tuple := range tuples {
+		key, err := Encode(tuple...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := 1
+		if i > 0 && testCompareTuples(tuples[i-1], tuple) == 0 {
+			expected = 0
+		}
+
+		if i > 0 && bytes.Compare(key, last) != expected {
+			t.Fatalf("invalid order, %v was encoded before %v", tuples[i-1], tuple)
+		}
+
+		last = key
+	}
+}
+
+func TestPrefixEnd(t *testing.T) {
+	prefix := AppendString(AppendString(nil, "tenant"), "123")
+	end := PrefixEnd(prefix)
+
+	inside := AppendInt(append([]byte(nil), prefix...), math.MaxInt64)
+	if bytes.Compare(inside, end) != -1 {
+		t.Fatalf("invalid prefix end, expected %v to sort before %v", inside, end)
+	}
+
+	outside := AppendString(AppendString(nil, "tenant"), "124")
+	if bytes.Compare(outside, end) == -1 {
+		t.Fatalf("invalid prefix end, expected %v to sort after %v", outside, end)
+	}
+
+	if end = PrefixEnd([]byte{0xFF, 0xFF}); end != nil {
+		t.Fatalf("invalid prefix end, expected nil and received %v", end)
+	}
+}
+
+func testRandomValue() any {
+	switch rand.Intn(7) {
+	case 0:
+		return []byte(testRandomString())
+	case 1:
+		return testRandomString()
+	case 2:
+		return rand.Intn(2) == 0
+	case 3:
+		return rand.Int63n(2000) - 1000
+	case 4:
+		return uint64(rand.Int63n(2000))
+	case 5:
+		return (rand.Float64() - 0.5) * math.Pow(10, float64(rand.Intn(10)))
+	default:
+		return time.Unix(rand.Int63n(1<<34)-1<<33, rand.Int63n(1e9)).UTC()
+	}
+}
+
+func testRandomString() string {
+	const chars = "\x00\x01ab\xff"
+	var sb strings.Builder
+	for i := rand.Intn(4); i > 0; i-- {
+		sb.WriteByte(chars[rand.Intn(len(chars))])
+	}
+
+	return sb.String()
+}
+
+func testCompareTuples(a, b []any) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := testCompareValues(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+
+	return testCompare(len(a), len(b))
+}
+
+func testCompareValues(a, b any) int {
+	if ta, tb := testTypeOrder(a), testTypeOrder(b); ta != tb {
+		return testCompare(ta, tb)
+	}
+
+	switch av := a.(type) {
+	case []byte:
+		return bytes.Compare(av, b.([]byte))
+	case string:
+		return strings.Compare(av, b.(string))
+	case bool:
+		return testCompare(testBoolInt(av), testBoolInt(b.(bool)))
+	case int64:
+		return testCompare(av, b.(int64))
+	case uint64:
+		return testCompare(av, b.(uint64))
+	case float64:
+		return testCompare(av, b.(float64))
+	case time.Time:
+		return av.Compare(b.(time.Time))
+	}
+
+	panic("unsupported type")
+}
+
+func testTypeOrder(v any) int {
+	switch v.(type) {
+	case []byte:
+		return 0
+	case string:
+		return 1
+	case bool:
+		return 2
+	case int64:
+		return 3
+	case uint64:
+		return 4
+	case float64:
+		return 5
+	default:
+		return 6
+	}
+}
+
+func testBoolInt(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+func testCompare[T int | int64 | uint64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
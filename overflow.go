@@ -0,0 +1,67 @@
+package rbt
+
+import "unsafe"
+
+const (
+	// OverflowThreshold is the value size (in bytes) at which values are moved out of their blob and
+	// into an overflow extent. Keeping large values apart from the blocks and keys keeps traversals
+	// compact and allows growing values without moving their keys.
+	OverflowThreshold = 4 * 1024
+	// OverflowPageSize is the size (in bytes) overflow extents are rounded up to
+	OverflowPageSize = 4 * 1024
+)
+
+// isOverflow will return whether or not a value of the provided capacity belongs in an overflow extent
+func isOverflow(vcap int64) bool {
+	return vcap >= OverflowThreshold
+}
+
+// getExtentSize will return the size of the overflow extent needed to hold the provided capacity
+func getExtentSize(vcap int64) int64 {
+	return (vcap + OverflowPageSize - 1) / OverflowPageSize * OverflowPageSize
+}
+
+// keyBlob will return the offset of the blob to use for a block when moving it's value into a new
+// allocation of blobLen bytes (not including the blob header). The existing blob is reused when both the
+// current and new values are stored in overflow extents, otherwise a new blob is allocated and the key
+// is written to it.
+func (t *Tree) keyBlob(b *Block, key []byte, blobLen int64, overflow bool) (boffset int64, grew bool) {
+	if overflow && t.getOverflowOffset(b) != -1 {
+		// Blob only holds the key, it can be kept as is
+		return b.getBlobOffset(), false
+	}
+
+	// Only the portion of the key which is not covered by the block's prefix is stored
+	stored := key[len(key)-int(t.getStoredLen(b, key)):]
+	flags := b.flags
+	hsz := getBlobHeaderSize(flags)
+	boffset, grew = t.alloc(hsz + blobLen)
+	writeSlot(unsafe.Pointer(&t.bs[boffset+blobKeyLen*getSlotSize(flags)]), flags, int64(len(stored)))
+	copy(t.bs[boffset+hsz:], stored)
+	return
+}
+
+// releaseBlob will release the allocations held for a block's value, the blob is retained when it
+// matches the provided blob offset
+func (t *Tree) releaseBlob(b *Block, boffset int64) {
+	if b.flags&blockInline != 0 {
+		// Release the inline region, keeping the slot which will hold the blob offset
+		ssz := getSlotSize(b.flags)
+		t.free(t.getKeyOffset(b)+ssz, t.getKeyLen(b)+t.getValCap(b)-ssz)
+		b.flags &^= blockInline | blockValue
+		return
+	}
+
+	if !t.hasBlob(b) {
+		return
+	}
+
+	ooffset, extent := t.getOverflowOffset(b), getExtentSize(t.getValCap(b))
+	if b.getBlobOffset() != boffset {
+		t.free(b.getBlobOffset(), t.getBlobSize(b))
+	}
+
+	if ooffset != -1 {
+		t.freeOverflow(ooffset, extent)
+	}
+}
@@ -0,0 +1,107 @@
+package rbt
+
+import (
+	"bytes"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func TestOverflow(t *testing.T) {
+	w := New(1024)
+	large := make([]byte, 1024*1024)
+	rand.Read(large)
+
+	for i := 0; i < 32; i++ {
+		key := []byte(strconv.Itoa(i))
+		if i%4 == 0 {
+			w.Put(key, large)
+			continue
+		}
+
+		w.Put(key, key)
+	}
+
+	for i := 0; i < 32; i++ {
+		key := []byte(strconv.Itoa(i))
+		offset := w.seekBlock(w.getHeader().root, key)
+		b := w.getBlock(offset)
+		if i%4 == 0 {
+			if w.getOverflowOffset(b) == -1 {
+				t.Fatalf("invalid overflow offset, expected an overflow extent for \"%s\"", key)
+			}
+
+			if !bytes.Equal(w.Get(key), large) {
+				t.Fatalf("invalid value for \"%s\"", key)
+			}
+
+			continue
+		}
+
+		if w.getOverflowOffset(b) != -1 {
+			t.Fatalf("invalid overflow offset, expected %d and received %d", -1, w.getOverflowOffset(b))
+		}
+
+		if val := string(w.Get(key)); val != string(key) {
+			t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", key, val)
+		}
+	}
+
+	if n := len(w.AppendKeys(nil)); n != 32 {
+		t.Fatalf("invalid number of keys, expected %d and received %d", 32, n)
+	}
+
+	if err := testValidate(w); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOverflowReuse(t *testing.T) {
+	w := New(1024)
+	large := make([]byte, 1024*1024)
+	rand.Read(large)
+
+	w.Put([]byte("small"), []byte("value"))
+	w.Put([]byte("large"), large)
+	offset := w.seekBlock(w.getHeader().root, []byte("large"))
+	extent := w.getOverflowOffset(w.getBlock(offset))
+
+	// Values which fill the same extent are written in place
+	w.Put([]byte("large"), large[:len(large)-100])
+	if b := w.getBlock(offset); w.getOverflowOffset(b) != extent {
+		t.Fatalf("invalid overflow offset, expected %d and received %d", extent, w.getOverflowOffset(b))
+	}
+
+	if !bytes.Equal(w.Get([]byte("large")), large[:len(large)-100]) {
+		t.Fatal("invalid value for \"large\"")
+	}
+
+	// Extents are reused once released
+	w.Put([]byte("tail"), []byte("value"))
+	size := w.Size()
+	w.Delete([]byte("large"))
+	w.Put([]byte("other"), large)
+
+	// Note: The key blob is too small to be tracked by the free lists and is allocated from the tail
+	if w.Size() > size+int64(len("other")) {
+		t.Fatalf("invalid size, expected no more than %d and received %d", size+int64(len("other")), w.Size())
+	}
+
+	// Shrinking an overflowed value moves it back alongside it's key
+	w.Put([]byte("other"), []byte("value"))
+	offset = w.seekBlock(w.getHeader().root, []byte("other"))
+	if b := w.getBlock(offset); w.getOverflowOffset(b) != -1 {
+		t.Fatalf("invalid overflow offset, expected %d and received %d", -1, w.getOverflowOffset(b))
+	}
+
+	// Appending beyond the threshold moves the value into an overflow extent
+	var expected []byte
+	for i := 0; i < 1000; i++ {
+		w.Append([]byte("small"), []byte("value"))
+		expected = append(expected, "value"...)
+	}
+
+	if val := w.Get([]byte("small")); !bytes.Equal(val, append([]byte("value"), expected...)) {
+		t.Fatalf("invalid value, expected %d bytes and received %d bytes", len(expected)+5, len(val))
+	}
+}
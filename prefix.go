@@ -0,0 +1,138 @@
+package rbt
+
+import (
+	"bytes"
+	"unsafe"
+)
+
+const (
+	// PrefixSize is the size (in bytes) of the shared prefix headers
+	PrefixSize = int64(unsafe.Sizeof(prefix{}))
+	// minPrefixLen is the smallest shared prefix (in bytes) which will be compressed, shorter prefixes
+	// would not save enough to make up for the prefix header
+	minPrefixLen = PrefixSize
+)
+
+// prefix is written to the start of every shared key prefix and is followed by the prefix bytes
+type prefix struct {
+	// Number of blocks referencing the prefix
+	refs int64
+	len  int64
+}
+
+// SetPrefixCompression will enable or disable key prefix compression for the tree and it's buckets.
+// When enabled, new keys which share a long enough prefix with a neighboring key will reference a
+// shared copy of the prefix rather than storing it in full.
+// Note: The setting is not persisted. Compressed keys remain readable regardless of the setting, and
+// keys provided by ForEach and iterators are copies rather than references to the tree's storage.
+func (t *Tree) SetPrefixCompression(enabled bool) {
+	t.prefixes = enabled
+}
+
+// acquirePrefix will return a prefix for a new key which shares the prefix of it's parent. The prefix
+// of the parent is referenced when the key shares all of it, otherwise a new prefix is created.
+// Note: An offset of -1 is returned when the key should be stored in full
+func (t *Tree) acquirePrefix(parent int64, key []byte) (offset, plen int64) {
+	if !t.prefixes || parent == -1 {
+		return -1, 0
+	}
+
+	pb := t.getBlock(parent)
+	shared := t.getSharedLen(key, pb)
+	if shared < minPrefixLen {
+		return -1, 0
+	}
+
+	if pb.getPrefixOffset() != -1 {
+		if p := t.getPrefixHeader(pb.getPrefixOffset()); p.len <= shared {
+			// Key shares the entire prefix of it's parent, reference it
+			p.refs++
+			return pb.getPrefixOffset(), p.len
+		}
+	}
+
+	offset, _ = t.alloc(PrefixSize + shared)
+	p := t.getPrefixHeader(offset)
+	p.refs = 1
+	p.len = shared
+	copy(t.bs[offset+PrefixSize:], key[:shared])
+	return offset, shared
+}
+
+// releasePrefix will release a block's reference to it's prefix, the prefix is freed once it is no longer referenced
+// Note: The block continues to reference the prefix, releasePrefix is only called when releasing the block
+func (t *Tree) releasePrefix(b *Block) {
+	if b.getPrefixOffset() == -1 {
+		return
+	}
+
+	p := t.getPrefixHeader(b.getPrefixOffset())
+	if p.refs--; p.refs == 0 {
+		t.free(b.getPrefixOffset(), PrefixSize+p.len)
+	}
+}
+
+// getSharedLen will return the length of the prefix shared by a key and a block's key
+func (t *Tree) getSharedLen(key []byte, b *Block) (n int64) {
+	for _, part := range [2][]byte{t.getPrefix(b), t.getStoredKey(b)} {
+		for _, c := range part {
+			if n == int64(len(key)) || key[n] != c {
+				return
+			}
+
+			n++
+		}
+	}
+
+	return
+}
+
+// compareKey will compare a key against a block's key without reconstructing the block's key
+func (t *Tree) compareKey(key []byte, b *Block) int {
+	return t.compareAbbr(key, getAbbr(key), b)
+}
+
+// compareAbbr will compare a key along with it's abbreviation against a block's key. The full keys are only
+// compared when the abbreviations are equal.
+func (t *Tree) compareAbbr(key []byte, abbr uint64, b *Block) int {
+	switch {
+	case abbr < b.abbr:
+		return -1
+	case abbr > b.abbr:
+		return 1
+	}
+
+	if b.getPrefixOffset() == -1 {
+		return bytes.Compare(key, t.getStoredKey(b))
+	}
+
+	p := t.getPrefix(b)
+	if len(key) < len(p) {
+		if c := bytes.Compare(key, p[:len(key)]); c != 0 {
+			return c
+		}
+
+		// Key is a prefix of the block's key
+		return -1
+	}
+
+	if c := bytes.Compare(key[:len(p)], p); c != 0 {
+		return c
+	}
+
+	return bytes.Compare(key[len(p):], t.getStoredKey(b))
+}
+
+// getPrefix will return the shared prefix of a block's key, nil is returned for keys stored in full
+func (t *Tree) getPrefix(b *Block) []byte {
+	if b.getPrefixOffset() == -1 {
+		return nil
+	}
+
+	start := b.getPrefixOffset() + PrefixSize
+	return t.bs[start : start+t.getPrefixHeader(b.getPrefixOffset()).len]
+}
+
+func (t *Tree) getPrefixHeader(offset int64) (p *prefix) {
+	return (*prefix)(unsafe.Pointer(&t.bs[offset]))
+}
@@ -0,0 +1,80 @@
+package rbt
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestPrefixCompression(t *testing.T) {
+	var keys [][]byte
+	for _, i := range rand.Perm(1000) {
+		keys = append(keys, []byte(fmt.Sprintf("org/1234/users/%05d", i)))
+	}
+
+	plain := New(1024)
+	w := New(1024)
+	w.SetPrefixCompression(true)
+	for _, key := range keys {
+		plain.Put(key, []byte("value"))
+		w.Put(key, []byte("value"))
+	}
+
+	if w.Size() >= plain.Size() {
+		t.Fatalf("invalid size, expected less than %d and received %d", plain.Size(), w.Size())
+	}
+
+	for _, key := range keys {
+		if val := string(w.Get(key)); val != "value" {
+			t.Fatalf("invalid value for \"%s\", expected \"%s\" and received \"%s\"", key, "value", val)
+		}
+	}
+
+	if w.Get([]byte("org/1234/users")) != nil || w.Get([]byte("org/1234/users/000000")) != nil {
+		t.Fatal("invalid value, expected nil for missing keys")
+	}
+
+	var i int
+	w.ForEach(func(key, _ []byte) (end bool) {
+		if expected := fmt.Sprintf("org/1234/users/%05d", i); string(key) != expected {
+			t.Fatalf("invalid key, expected \"%s\" and received \"%s\"", expected, key)
+		}
+
+		i++
+		return
+	})
+
+	if i != 1000 {
+		t.Fatalf("invalid number of items, expected %d and received %d", 1000, i)
+	}
+
+	if n := w.DeleteRange([]byte("org/1234/users/00100"), []byte("org/1234/users/00200")); n != 100 {
+		t.Fatalf("invalid number of deleted items, expected %d and received %d", 100, n)
+	}
+
+	if err := testValidate(w); err != nil {
+		t.Fatal(err)
+	}
+
+	// Prefixes are released once every key referencing them has been removed
+	last := []byte("org/1234/users/00042")
+	for _, key := range keys {
+		if !bytes.Equal(key, last) {
+			w.Delete(key)
+		}
+	}
+
+	b := w.getBlock(w.seekBlock(w.getHeader().root, last))
+	if b.getPrefixOffset() != -1 {
+		if refs := w.getPrefixHeader(b.getPrefixOffset()).refs; refs != 1 {
+			t.Fatalf("invalid number of prefix references, expected %d and received %d", 1, refs)
+		}
+	}
+
+	// Compressed keys remain readable once compression is disabled
+	w.SetPrefixCompression(false)
+	if val := w.Get([]byte("org/1234/users/00042")); !bytes.Equal(val, []byte("value")) {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "value", val)
+	}
+}
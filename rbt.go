@@ -2,6 +2,8 @@ package rbt
 
 import (
 	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
 	"unsafe"
 
 	"github.com/itsmontoya/rbt/backend"
@@ -11,12 +13,40 @@ import (
 const (
 	// ErrCannotAllocate is returned when Tree cannot allocate the bytes it needs
 	ErrCannotAllocate = errors.Error("cannot allocate needed bytes")
+	// ErrBucketExists is returned when creating a bucket which already exists
+	ErrBucketExists = errors.Error("bucket already exists")
+	// ErrBucketNotFound is returned when a requested bucket does not exist
+	ErrBucketNotFound = errors.Error("bucket not found")
+	// ErrIncompatibleValue is returned (or panicked with) when a key holds a bucket where a value is expected,
+	// or a value where a bucket is expected
+	ErrIncompatibleValue = errors.Error("incompatible value")
+	// ErrKeyNotFound is returned when a requested key does not exist
+	ErrKeyNotFound = errors.Error("key not found")
+	// ErrInvalidFile is returned when opening a backend which does not hold a tree
+	ErrInvalidFile = errors.Error("invalid file, backend does not hold a tree")
+	// ErrUnsupportedVersion is returned when opening a tree written with an unsupported layout version
+	ErrUnsupportedVersion = errors.Error("unsupported version, tree was written with a different layout")
+	// ErrLegacyFile is returned when opening a tree written before layout versions were recorded
+	ErrLegacyFile = errors.Error("legacy file, tree was written before layout versions were recorded")
+	// ErrInvalidFormat is returned when an unknown format is provided
+	ErrInvalidFormat = errors.Error("invalid format")
+	// ErrCompactLimit is panicked with when a FormatCompact tree would grow beyond 4GB
+	ErrCompactLimit = errors.Error("compact trees cannot exceed 4GB")
+	// ErrCorruptTree is panicked with when a tree is deeper than any valid red-black tree can be
+	ErrCorruptTree = errors.Error("corrupt tree")
+)
+
+const (
+	// FormatWide stores the offsets and lengths of blocks as int64 values
+	FormatWide Format = iota
+	// FormatCompact stores the offsets and lengths of blocks as uint32 values, nearly halving the size of
+	// small entries. Compact trees cannot exceed 4GB, allocating beyond this will panic with ErrCompactLimit.
+	FormatCompact
 )
 
 const (
 	colorBlack color = iota
 	colorRed
-	colorDoubleBlack
 )
 
 const (
@@ -25,13 +55,49 @@ const (
 	childRight
 )
 
+const (
+	// blockInline is set for blocks which hold their key and value directly after the block
+	blockInline blockFlag = 1 << iota
+	// blockCompressed is set for blocks whose value has been compressed
+	blockCompressed
+	// blockEncrypted is set for blocks whose value has been encrypted
+	blockEncrypted
+	// blockBucket is set for blocks whose value holds the header of a bucket
+	blockBucket
+	// blockPrefixed is set for blocks which hold the offset of a shared key prefix
+	blockPrefixed
+	// blockValue is set for inline blocks once their value has been set
+	blockValue
+	// blockCompact is set for the blocks of FormatCompact trees, which hold uint32 slots
+	blockCompact
+)
+
 const (
 	// TrunkSize is the size (in bytes) of the trunks
 	TrunkSize = int64(unsafe.Sizeof(trunk{}))
-	// BlockSize is the size (in bytes) of the Blocks
+	// BlockSize is the size (in bytes) of the Blocks, not including the slots which follow them
 	BlockSize = int64(unsafe.Sizeof(Block{}))
+	// HeaderSize is the size (in bytes) of the tree headers
+	HeaderSize = int64(unsafe.Sizeof(header{}))
+	// InlineSize is the maximum size (in bytes) of a key and value which can be held inline by their Block
+	InlineSize = 64
 )
 
+// layoutVersion is the version of the layout written by this package, trees written with other
+// versions cannot be opened
+const layoutVersion = 3
+
+// magic is written to the start of every trunk
+var magic = [4]byte{'r', 'b', 't', 0}
+
+// rootHeader is the offset of the header for the root tree within the trunk
+var rootHeader = int64(unsafe.Offsetof(trunk{}.header))
+
+// maxDepth is the maximum depth of a tree. A red-black tree is at most twice as deep as it's shortest path,
+// so no valid tree addressable with 64-bit offsets can exceed this. Walks which go deeper than this have
+// encountered a cycle or other corruption.
+const maxDepth = 128
+
 // New will return a new Tree
 // sz is the size (in bytes) to initially allocate for this db
 func New(sz int64) (t *Tree) {
@@ -49,16 +115,36 @@ func NewMMAP(dir, name string, sz int64) (t *Tree, err error) {
 		return
 	}
 
-	return NewRaw(sz, mm)
+	if t, err = NewRaw(sz, mm); err != nil {
+		mm.Close()
+	}
+
+	return
 }
 
 // NewRaw will return a new Tree with the provided size, grow func, and close func
 // sz is the size (in bytes) to initially allocate for this db
 // gfn is the function to call on grows
 // cfn is the function to call on close (optional)
+// Note: ErrInvalidFile is returned for backends which hold something other than a tree and
+// ErrUnsupportedVersion is returned for trees written with a different layout version. ErrLegacyFile is
+// returned for trees written before layout versions were recorded, these must be copied into a new tree
+// using the release which wrote them (see the README).
 func NewRaw(sz int64, b backend.Backend) (tp *Tree, err error) {
+	return NewRawFormat(sz, b, FormatWide)
+}
+
+// NewRawFormat will return a new Tree the same as NewRaw, new trees are created using the provided format
+// Note: The format is recorded within the trunk, existing trees are opened using their recorded format
+func NewRawFormat(sz int64, b backend.Backend, f Format) (tp *Tree, err error) {
+	if f != FormatWide && f != FormatCompact {
+		err = ErrInvalidFormat
+		return
+	}
+
 	var t Tree
-	t.b = b
+	t.storage = &storage{b: b}
+	t.h = rootHeader
 
 	if sz < TrunkSize {
 		sz = TrunkSize
@@ -69,13 +155,36 @@ func NewRaw(sz int64, b backend.Backend) (tp *Tree, err error) {
 		return
 	}
 
+	// A trunk which has not been set will be entirely zeroed
+	initialized := !isZero(t.bs[:TrunkSize])
 	t.setLabel()
-	// Check if trunk has been initialized
-	if t.t.tail == 0 {
+	switch {
+	case t.t.magic == magic:
+		if t.t.version != layoutVersion {
+			err = ErrUnsupportedVersion
+			return
+		}
+	case isLegacy(t.bs):
+		err = ErrLegacyFile
+		return
+	case initialized:
+		err = ErrInvalidFile
+		return
+	default:
 		// trunk has not been set, set inital values
+		t.t.magic = magic
+		t.t.version = layoutVersion
+		t.t.format = f
 		t.t.root = -1
+		t.t.bloom = -1
 		t.t.tail = TrunkSize
 		t.t.cap = sz
+		t.resetFree()
+
+		// Each file has it's own salt for deriving encryption keys
+		if _, err = rand.Read(t.t.salt[:]); err != nil {
+			return
+		}
 	}
 
 	tp = &t
@@ -84,176 +193,384 @@ func NewRaw(sz int64, b backend.Backend) (tp *Tree, err error) {
 
 // Tree is a red-black tree data structure
 type Tree struct {
+	*storage
+
+	// Offset of the header which holds the root and count for this tree
+	h int64
+	// Number of buckets this tree is nested within
+	depth int
+}
+
+// storage is shared between a tree and it's buckets
+type storage struct {
 	bs []byte
 	t  *trunk
 
 	b backend.Backend
+
+	// Whether or not key prefix compression is enabled
+	prefixes bool
+
+	// Compressor used for values, values are stored raw when nil
+	compressor Compressor
+	// Scratch buffer for compressed values, reused between calls
+	cbuf []byte
+
+	// KeyProvider used to encrypt values, values are stored unencrypted when nil
+	keys KeyProvider
+	// Ciphers derived from the provided keys, keyed by key ID
+	aeads map[uint32]cipher.AEAD
+	// Scratch buffer for encrypted values, reused between calls
+	ebuf []byte
 }
 
 // Get will retrieve an item from a tree
+// Note: Get will panic when an encoded value cannot be decoded, such as a compressed value without a Compressor
+// or an encrypted value without it's key (ErrKeyUnavailable)
 func (t *Tree) Get(key []byte) (val []byte) {
-	if offset, _ := t.seekBlock(t.t.root, key, false); offset != -1 {
-		// Node was found, set value as the node's value
-		val = t.getValue(t.getBlock(offset))
+	val, _ = t.get(key)
+	return
+}
+
+// get will retrieve an item from a tree, ok is false when the key does not exist
+// Note: Empty values may be returned as nil, ok should be used to check whether or not the key exists
+func (t *Tree) get(key []byte) (val []byte, ok bool) {
+	if !t.mayContain(key) {
+		return
 	}
 
-	return
+	offset := t.seekBlock(t.getHeader().root, key)
+	if offset == -1 {
+		return
+	}
+
+	// Node was found, set value as the node's value
+	return t.readValue(t.getBlock(offset)), true
+}
+
+// Has will return whether or not a key exists within the tree
+func (t *Tree) Has(key []byte) bool {
+	if !t.mayContain(key) {
+		return false
+	}
+
+	return t.seekBlock(t.getHeader().root, key) != -1
 }
 
 // Put will insert an item into the tree
 func (t *Tree) Put(key, val []byte) {
-	var (
-		b      *Block
-		grew   bool
-		offset int64
-	)
-
-	if t.t.root == -1 {
-		// Root doesn't exist, we can create one
-		b, offset, _ = t.newBlock(key)
-		t.t.root = offset
-	} else {
-		// Find node whose key matches our provided key, if node does not exist - create it.
-		offset, _ = t.seekBlock(t.t.root, key, true)
+	t.put(t.getHeader().root, key, val)
+}
+
+// put will insert an item into the tree, searching for it's position from the provided block. The offset of
+// the item's block is returned.
+func (t *Tree) put(startOffset int64, key, val []byte) (offset int64) {
+	offset = t.createBlockFrom(startOffset, key, int64(len(val)))
+	b := t.getBlock(offset)
+
+	// Blocks which have just been created will not have a blob yet
+	created := !b.hasValue()
+	if grew := t.setBlob(b, key, val); grew {
 		b = t.getBlock(offset)
 	}
 
-	if grew = t.setBlob(b, key, val); grew {
+	if created {
+		t.insertBalance(b)
+	}
+
+	return
+}
+
+// PutIfAbsent will insert an item into the tree if the key does not already exist
+// True is returned when the item has been inserted
+func (t *Tree) PutIfAbsent(key, val []byte) (inserted bool) {
+	offset := t.createBlock(key, int64(len(val)))
+	b := t.getBlock(offset)
+	if b.hasValue() {
+		// Key already exists
+		return
+	}
+
+	if grew := t.setBlob(b, key, val); grew {
 		b = t.getBlock(offset)
 	}
 
-	// Balance tree after insert
-	// TODO: This can be moved into the node-creation portion
-	t.balance(b)
+	t.insertBalance(b)
+	return true
+}
 
-	root := t.getBlock(t.t.root)
-	if root.ct != childRoot {
-		// Root has changed, update root reference to the new root
-		t.t.root = root.parent
+// CompareAndSwap will replace the value for a key if the current value matches old
+// True is returned when the value has been swapped
+func (t *Tree) CompareAndSwap(key, old, new []byte) (swapped bool) {
+	offset := t.seekBlock(t.getHeader().root, key)
+	if offset == -1 {
+		return
 	}
 
-	t.t.cnt++
+	b := t.getBlock(offset)
+	if b.flags&blockBucket != 0 || !bytes.Equal(t.readValue(b), old) {
+		return
+	}
+
+	t.setBlob(b, key, new)
+	return true
 }
 
-// Delete will remove an item from the tree
-func (t *Tree) Delete(key []byte) {
-	var (
-		b      *Block
-		next   *Block
-		offset int64
-	)
+// CompareAndDelete will remove an item from the tree if the current value matches old
+// True is returned when the item has been removed
+func (t *Tree) CompareAndDelete(key, old []byte) (deleted bool) {
+	offset := t.seekBlock(t.getHeader().root, key)
+	if offset == -1 {
+		return
+	}
 
-	if offset, _ = t.seekBlock(t.t.root, key, false); offset == -1 {
+	b := t.getBlock(offset)
+	if b.flags&blockBucket != 0 || !bytes.Equal(t.readValue(b), old) {
 		return
 	}
 
-	b = t.getBlock(offset)
-	parent := t.getBlock(b.parent)
-	hasLeft := b.children[0] != -1
-	hasRight := b.children[1] != -1
+	t.deleteBlock(b)
+	return true
+}
+
+// Append will append data to the value stored for a key, the key is created if it does not exist.
+// Capacity is doubled as needed so repeated appends are amortized O(1), Get will only return the
+// bytes which have been written.
+// Note: While encryption is enabled, the entire value is re-sealed on each append
+func (t *Tree) Append(key, data []byte) {
+	if t.keys != nil {
+		var val []byte
+		if offset := t.seekBlock(t.getHeader().root, key); offset != -1 {
+			val = t.readValue(t.getBlock(offset))
+		}
+
+		// Limit capacity so the appended value never writes into the tree's storage
+		t.Put(key, append(val[:len(val):len(val)], data...))
+		return
+	}
+
+	offset := t.createBlock(key, int64(len(data)))
+	b := t.getBlock(offset)
+	if t.isStorage(data) {
+		// Growing the blob may remap our storage, copy data which references it
+		data = append([]byte(nil), data...)
+	}
+
+	// Encoded values are decoded before being appended to
+	if grew := t.decodeBlob(b, key); grew {
+		b = t.getBlock(offset)
+	}
+
+	created := !b.hasValue()
+	sz := t.getValLen(b) + int64(len(data))
+	if created || sz > t.getValCap(b) {
+		if grew := t.growBlob(b, key, sz); grew {
+			b = t.getBlock(offset)
+		}
+	}
+
+	copy(t.bs[t.getValueIndex(b)+t.getValLen(b):], data)
+	t.setValLen(b, sz)
 
-	// BST Delete switch
+	if created {
+		t.insertBalance(b)
+	}
+}
+
+// Upsert will locate the item for a key and replace it's value with the value returned by fn. If the
+// key does not exist, fn will be called with a nil value and exists set to false. When del is returned
+// as true, the item is removed instead. Values with an unchanged length are written in place.
+// Note: The old value is only valid for the duration of the call and the tree must not be modified within fn
+func (t *Tree) Upsert(key []byte, fn UpsertFn) {
+	// The size of the new value is unknown, the block is not created inline
+	offset := t.createBlock(key, -1)
+	b := t.getBlock(offset)
+	checkValue(b)
+
+	var old []byte
+	exists := b.hasValue()
+	if exists {
+		// Limit capacity so appending to the old value cannot write into neighboring data
+		old = t.readValue(b)
+		old = old[:len(old):len(old)]
+	}
+
+	new, del := fn(old, exists)
 	switch {
-	case hasLeft && hasRight:
-		next = t.twoChildDelete(b, parent)
+	case del && exists:
+		t.deleteBlock(b)
+		return
+	case del:
+		// Block was only created for this call, it can be removed without balancing
+		t.replace(b, nil, t.getBlock(b.getParent()))
+		t.freeBlock(b)
+		return
+	}
 
-	case !hasLeft && !hasRight:
-		t.zeroChildrenDelete(b, parent)
-		// We are just using this as a placeholder for next
-		next = b
-	default:
-		// Technically this is out of order, but it seems much more clean to check to see
-		// if we have ALL or NONE. If neither cases exist, we know we have one child
-		next = t.oneChildDelete(b, parent)
+	if len(new) != len(old) && t.isStorage(new) {
+		// Writing a new blob may remap our storage, copy values which reference it
+		new = append([]byte(nil), new...)
+	}
 
+	if grew := t.setBlob(b, key, new); grew {
+		b = t.getBlock(offset)
 	}
 
-	// Balancing cases
-	if b.c == colorRed || next.c == colorRed {
-		// Simple Case: If either u or v is red
-		// Note: Because we are not disrupting the black-level, no rotation is needed
-		next.c = colorBlack
-	} else {
-		next.c = colorDoubleBlack
+	if !exists {
+		t.insertBalance(b)
 	}
+}
 
-	t.deleteBalance(next, parent)
+// Delete will remove an item from the tree
+func (t *Tree) Delete(key []byte) {
+	var offset int64
+	if offset = t.seekBlock(t.getHeader().root, key); offset == -1 {
+		return
+	}
+
+	t.deleteBlock(t.getBlock(offset))
+}
 
-	root := t.getBlock(t.t.root)
-	if root != nil && root.ct != childRoot {
-		// Root has changed, update root reference to the new root
-		t.t.root = root.parent
+// DeleteFunc will remove every item which fn returns true for, the number of removed items is returned
+// Note: The key and value provided to fn are only valid for the duration of the call
+func (t *Tree) DeleteFunc(fn DeleteFn) (n int) {
+	return t.deleteFunc(t.getHead(t.getHeader().root), nil, fn)
+}
+
+// DeleteRangeFunc will remove every item within the range of [start, end) which fn returns true for,
+// the number of removed items is returned
+// Note: A nil start or end will leave that side of the range unbounded
+func (t *Tree) DeleteRangeFunc(start, end []byte, fn DeleteFn) (n int) {
+	return t.deleteFunc(t.seekStart(start), end, fn)
+}
+
+// DeleteRange will remove every item within the range of [start, end), the number of removed items is returned
+// Note: A nil start or end will leave that side of the range unbounded
+func (t *Tree) DeleteRange(start, end []byte) (n int) {
+	// Split out the trees before and after the range
+	left, mid := t.split(t.getHeader().root, start, 0)
+	right := int64(-1)
+	if end != nil {
+		mid, right = t.split(mid, end, 0)
 	}
 
-	t.t.cnt--
+	// Join the remaining trees back together and release everything in between
+	t.getHeader().root = t.merge(left, right)
+	n = t.freeTree(mid, 0)
+	t.getHeader().cnt -= int64(n)
+	return
 }
 
 // ForEach will iterate through each tree item
 func (t *Tree) ForEach(fn ForEachFn) (ended bool) {
-	if t.t.root == -1 {
+	if t.getHeader().root == -1 {
 		// Root doesn't exist, return early
 		return
 	}
 
 	// Call iterate from root
-	return t.iterate(t.getBlock(t.t.root), fn)
+	return t.iterate(t.getBlock(t.getHeader().root), fn)
+}
+
+// ForEachKey will iterate through each tree key without accessing values
+func (t *Tree) ForEachKey(fn ForEachKeyFn) (ended bool) {
+	if t.getHeader().root == -1 {
+		// Root doesn't exist, return early
+		return
+	}
+
+	// Call iterateKeys from root
+	return t.iterateKeys(t.getBlock(t.getHeader().root), fn)
+}
+
+// AppendKeys will append each tree key to dst in ascending order without accessing values
+// Note: The appended keys reference the tree's storage and are only valid until the tree is modified,
+// unless prefix compression is in use
+func (t *Tree) AppendKeys(dst [][]byte) [][]byte {
+	t.ForEachKey(func(key []byte) (end bool) {
+		dst = append(dst, key)
+		return
+	})
+
+	return dst
 }
 
 // Grow will grow a blob value to a given size
+// Note: The returned bytes are written to in place, Grow will panic with ErrEncryptedInPlace while
+// encryption is enabled
 func (t *Tree) Grow(key []byte, sz int64) (bs []byte) {
-	var (
-		b      *Block
-		grew   bool
-		offset int64
-	)
-
-	if t.t.root == -1 {
-		// Root doesn't exist, we can create one
-		b, offset, _ = t.newBlock(key)
-		t.t.root = offset
-	} else {
-		// Find node whose key matches our provided key, if node does not exist - create it.
-		offset, _ = t.seekBlock(t.t.root, key, true)
-		b = t.getBlock(offset)
+	if t.keys != nil {
+		panic(ErrEncryptedInPlace)
 	}
 
-	if grew = t.growBlob(b, key, sz); grew {
+	return t.growValue(key, sz)
+}
+
+// growValue will grow a blob value to a given size and return the value for writing in place
+func (t *Tree) growValue(key []byte, sz int64) (bs []byte) {
+	offset := t.createBlock(key, sz)
+	b := t.getBlock(offset)
+	checkValue(b)
+	if grew := t.decodeBlob(b, key); grew {
 		b = t.getBlock(offset)
 	}
 
-	// Balance tree after insert
-	// TODO: This can be moved into the node-creation portion
-	t.balance(b)
+	created := !b.hasValue()
+	if grew := t.growBlob(b, key, sz); grew {
+		b = t.getBlock(offset)
+	}
 
-	root := t.getBlock(t.t.root)
-	if root.ct != childRoot {
-		// Root has changed, update root reference to the new root
-		t.t.root = root.parent
+	if created {
+		t.insertBalance(b)
 	}
 
+	// Grow exposes the entire capacity as the value
+	t.setValLen(b, t.getValCap(b))
 	bs = t.getValue(b)
 	return
 }
 
 // Reset will clear the tree and keep the backend. Can be used as a fresh store
+// Note: When called on a bucket, only the contents of the bucket (including nested buckets) are cleared
 func (t *Tree) Reset() {
+	if t.depth != 0 {
+		t.freeBucket()
+		return
+	}
+
+	// Bloom filters are released along with everything else, keep the sizing so it can be re-enabled
+	var n int
+	var p float64
+	if offset := t.t.bloom; offset != -1 {
+		n, p = int(t.getBloom(offset).n), t.getBloom(offset).p
+	}
+
 	t.t.tail = TrunkSize
 	t.t.root = -1
+	t.t.cnt = 0
+	t.t.bloom = -1
+	t.resetFree()
+
+	if n > 0 {
+		t.EnableBloomFilter(n, p)
+	}
 }
 
 // Len will return the length of the data-store
 func (t *Tree) Len() (n int) {
-	return int(t.t.cnt)
+	return int(t.getHeader().cnt)
 }
 
-// Size will return the number of bytes currently being utilized (not total allocated bytes)
+// Size will return the number of bytes which have been allocated from the backend (not total backend bytes)
+// Note: Released space is kept on free lists for reuse, so Size does not shrink as items are deleted
 func (t *Tree) Size() int64 {
 	return t.t.tail
 }
 
 // Close will close a tree
+// Note: Buckets share the backend of their parent, closing a bucket has no effect
 func (t *Tree) Close() (err error) {
-	if t.b == nil {
+	if t.b == nil || t.depth != 0 {
 		return
 	}
 
@@ -263,44 +580,60 @@ func (t *Tree) Close() (err error) {
 // getHead will get the very first item starting from a given node
 // Note: If called from root, will return the first item in the tree
 func (t *Tree) getHead(startOffset int64) (offset int64) {
-	offset = -1
-
-	if startOffset == -1 {
-		return
-	}
+	offset = startOffset
+	for depth := 0; offset != -1; depth++ {
+		checkDepth(depth)
+		child := t.getBlock(offset).getChild(0)
+		if child == -1 {
+			return
+		}
 
-	b := t.getBlock(startOffset)
-	if child := b.children[0]; child != -1 {
-		return t.getHead(child)
+		offset = child
 	}
 
-	return startOffset
+	return
 }
 
 func (t *Tree) getUncle(startOffset int64) (offset int64) {
 	offset = -1
 	block := t.getBlock(startOffset)
-	parent := t.getBlock(block.parent)
+	parent := t.getBlock(block.getParent())
 	if parent == nil {
 		return
 	}
 
-	grandparent := t.getBlock(parent.parent)
+	grandparent := t.getBlock(parent.getParent())
 	if grandparent == nil {
 		return
 	}
 
 	switch parent.ct {
 	case childLeft:
-		return grandparent.children[1]
+		return grandparent.getChild(1)
 	case childRight:
-		return grandparent.children[0]
+		return grandparent.getChild(0)
 
 	}
 
 	return
 }
 
+func (t *Tree) getSibling(b *Block) (sibling *Block) {
+	parent := t.getBlock(b.getParent())
+	switch b.ct {
+	case childLeft:
+		return t.getBlock(parent.getChild(1))
+	case childRight:
+		return t.getBlock(parent.getChild(0))
+	}
+
+	return
+}
+
+func (t *Tree) getHeader() (h *header) {
+	return (*header)(unsafe.Pointer(&t.bs[t.h]))
+}
+
 func (t *Tree) getBlock(offset int64) (b *Block) {
 	if offset == -1 {
 		return
@@ -309,13 +642,227 @@ func (t *Tree) getBlock(offset int64) (b *Block) {
 	return (*Block)(unsafe.Pointer(&t.bs[offset]))
 }
 
+// getKey will return the key of a block
+// Note: Prefix compressed keys are reconstructed, all other keys reference the tree's storage
 func (t *Tree) getKey(b *Block) (key []byte) {
-	return t.bs[b.blobOffset : b.blobOffset+b.keyLen]
+	if b.getPrefixOffset() == -1 {
+		return t.getStoredKey(b)
+	}
+
+	p := t.getPrefix(b)
+	key = make([]byte, 0, int64(len(p))+t.getKeyLen(b))
+	key = append(key, p...)
+	return append(key, t.getStoredKey(b)...)
+}
+
+// getStoredKey will return the portion of a block's key which is stored within the block or it's blob
+func (t *Tree) getStoredKey(b *Block) (key []byte) {
+	offset := t.getKeyOffset(b)
+	return t.bs[offset : offset+t.getKeyLen(b)]
+}
+
+// getStoredLen will return the length of the portion of a key which is stored for a block
+// Note: The key is used for blocks whose blob has not been created yet
+func (t *Tree) getStoredLen(b *Block, key []byte) int64 {
+	return int64(len(key) - len(t.getPrefix(b)))
 }
 
 func (t *Tree) getValue(b *Block) (value []byte) {
-	valueIndex := b.blobOffset + b.keyLen
-	return t.bs[valueIndex : valueIndex+b.valLen]
+	valueIndex := t.getValueIndex(b)
+	return t.bs[valueIndex : valueIndex+t.getValLen(b)]
+}
+
+// hasBlob will return whether or not a block holds a blob, inline blocks and blocks whose value has not
+// been set do not
+func (t *Tree) hasBlob(b *Block) bool {
+	return b.flags&blockInline == 0 && b.getBlobOffset() != -1
+}
+
+// getBlobSlot will return a slot from the header of a block's blob
+func (t *Tree) getBlobSlot(b *Block, slot int) int64 {
+	return readSlot(t.getBlobSlotPointer(b, slot), b.flags)
+}
+
+// setBlobSlot will set a slot within the header of a block's blob
+func (t *Tree) setBlobSlot(b *Block, slot int, v int64) {
+	writeSlot(t.getBlobSlotPointer(b, slot), b.flags, v)
+}
+
+func (t *Tree) getBlobSlotPointer(b *Block, slot int) unsafe.Pointer {
+	return unsafe.Pointer(&t.bs[b.getBlobOffset()+int64(slot)*getSlotSize(b.flags)])
+}
+
+// getKeyOffset will return the offset of a block's stored key
+func (t *Tree) getKeyOffset(b *Block) int64 {
+	if b.flags&blockInline != 0 {
+		return b.getOffset() + b.getHeaderSize()
+	}
+
+	return b.getBlobOffset() + getBlobHeaderSize(b.flags)
+}
+
+func (t *Tree) getKeyLen(b *Block) int64 {
+	if t.hasBlob(b) {
+		return t.getBlobSlot(b, blobKeyLen)
+	}
+
+	return int64(b.inlineKeyLen)
+}
+
+func (t *Tree) getValLen(b *Block) int64 {
+	if t.hasBlob(b) {
+		return t.getBlobSlot(b, blobValLen)
+	}
+
+	return int64(b.inlineValLen)
+}
+
+func (t *Tree) setValLen(b *Block, valLen int64) {
+	if t.hasBlob(b) {
+		t.setBlobSlot(b, blobValLen, valLen)
+		return
+	}
+
+	b.inlineValLen = uint8(valLen)
+}
+
+// getValCap will return the capacity of a block's value, blocks which are not inline have no capacity
+// until their value has been set
+func (t *Tree) getValCap(b *Block) int64 {
+	if t.hasBlob(b) {
+		return t.getBlobSlot(b, blobValCap)
+	}
+
+	return int64(b.inlineValCap)
+}
+
+// getOverflowOffset will return the offset of the overflow extent holding a block's value, -1 is returned
+// when the value directly follows the key
+func (t *Tree) getOverflowOffset(b *Block) int64 {
+	if t.hasBlob(b) {
+		return t.getBlobSlot(b, blobOverflow)
+	}
+
+	return -1
+}
+
+// readValue will return the value of a block, encoded values are decrypted and decompressed into a new slice
+// Note: The values of buckets are returned as nil. Encoded values which cannot be decoded will panic, so they
+// are never mistaken for an empty value (and written back over the stored value).
+func (t *Tree) readValue(b *Block) (value []byte) {
+	var err error
+	if value, err = t.decodeValue(b); err != nil {
+		panic(err)
+	}
+
+	return
+}
+
+// decodeValue will return the value of a block, encoded values are decrypted and decompressed into a new slice.
+// An error is returned when an encoded value cannot be decoded.
+func (t *Tree) decodeValue(b *Block) (value []byte, err error) {
+	if b.flags&blockBucket != 0 {
+		return
+	}
+
+	value = t.getValue(b)
+	if !isEncoded(b) {
+		return
+	}
+
+	if b.flags&blockEncrypted != 0 {
+		if value, err = t.decrypt(t.getKey(b), value); err != nil {
+			return nil, err
+		}
+	}
+
+	if b.flags&blockCompressed != 0 {
+		if t.compressor == nil {
+			return nil, ErrCompressorUnavailable
+		}
+
+		if value, err = t.compressor.Decompress(nil, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
+
+// decodeBlob will replace a block's encoded value with it's raw value
+// Note: decodeBlob will panic when the value cannot be decoded, leaving the stored value as is
+func (t *Tree) decodeBlob(b *Block, key []byte) (grew bool) {
+	if !isEncoded(b) {
+		return
+	}
+
+	value := t.readValue(b)
+	offset := b.getOffset()
+	if grew = t.writeBlob(b, key, value); grew {
+		b = t.getBlock(offset)
+	}
+
+	b.flags &^= blockCompressed | blockEncrypted
+	return
+}
+
+// getValueIndex will return the offset of a block's value
+func (t *Tree) getValueIndex(b *Block) int64 {
+	if offset := t.getOverflowOffset(b); offset != -1 {
+		return offset
+	}
+
+	return t.getKeyOffset(b) + t.getKeyLen(b)
+}
+
+// isStorage will return whether or not the provided bytes reside within the tree's storage
+func (t *Tree) isStorage(bs []byte) bool {
+	if len(bs) == 0 || len(t.bs) == 0 {
+		return false
+	}
+
+	start := uintptr(unsafe.Pointer(&t.bs[0]))
+	ptr := uintptr(unsafe.Pointer(&bs[0]))
+	return ptr >= start && ptr < start+uintptr(len(t.bs))
+}
+
+// getBlobSize will return the number of bytes allocated for a block's blob
+// Note: Blobs of overflowed values only hold the header and key
+func (t *Tree) getBlobSize(b *Block) int64 {
+	sz := getBlobHeaderSize(b.flags) + t.getKeyLen(b)
+	if t.getOverflowOffset(b) != -1 {
+		return sz
+	}
+
+	return sz + t.getValCap(b)
+}
+
+// getBlockSize will return the number of bytes allocated for a block, including it's slots and any inline
+// key and value
+func (t *Tree) getBlockSize(b *Block) int64 {
+	if b.flags&blockInline != 0 {
+		return b.getHeaderSize() + int64(b.inlineKeyLen) + int64(b.inlineValCap)
+	}
+
+	return b.getHeaderSize() + getSlotSize(b.flags)
+}
+
+// freeBlock will release a block along with it's blob
+func (t *Tree) freeBlock(b *Block) {
+	if b.flags&blockBucket != 0 {
+		// Buckets release their contents before their block
+		bucket := t.newBucket(b)
+		bucket.freeBucket()
+		bucket.DisableBloomFilter()
+	}
+
+	t.releasePrefix(b)
+	if b.flags&blockInline == 0 {
+		// Inline blobs are released along with the block
+		t.releaseBlob(b, -1)
+	}
+
+	t.free(b.getOffset(), t.getBlockSize(b))
 }
 
 func (t *Tree) setLabel() {
@@ -323,159 +870,298 @@ func (t *Tree) setLabel() {
 	t.t.cap = int64(len(t.bs))
 }
 
+// setRoot will walk up from the current root until the top of the tree is found
+func (t *Tree) setRoot() {
+	root := t.getBlock(t.getHeader().root)
+	if root == nil {
+		return
+	}
+
+	for depth := 0; root.ct != childRoot; depth++ {
+		checkDepth(depth)
+		root = t.getBlock(root.getParent())
+	}
+
+	t.getHeader().root = root.getOffset()
+}
+
 func (t *Tree) setParentChild(b, parent, child *Block) {
 	switch b.ct {
 	case childLeft:
-		parent.children[0] = child.offset
+		parent.setChild(0, child.getOffset())
 	case childRight:
-		parent.children[1] = child.offset
+		parent.setChild(1, child.getOffset())
 	case childRoot:
-		// No action is taken, tree will handle this at the end of put
+		// No action is taken, tree will handle this with setRoot
 	}
 }
 
+// setBlob will set the value for a block. The value is compressed when a Compressor has been set and
+// encrypted when a KeyProvider has been set.
 func (t *Tree) setBlob(b *Block, key, value []byte) (grew bool) {
-	valLen := int64(len(value))
-	if valLen == b.valLen {
-		blobIndex := b.offset + BlockSize
-		valueIndex := blobIndex + b.keyLen
-		copy(t.bs[valueIndex:], value)
-		return
-	}
+	value, compressed := t.compress(value)
+	value, encrypted := t.encrypt(key, value)
 
-	var offset, boffset int64
-	offset = b.offset
-	if boffset, grew = t.newBlob(key, value); grew {
+	offset := b.getOffset()
+	if grew = t.writeBlob(b, key, value); grew {
 		b = t.getBlock(offset)
 	}
 
-	b.blobOffset = boffset
-	b.valLen = valLen
+	b.flags &^= blockCompressed | blockEncrypted
+	if compressed {
+		b.flags |= blockCompressed
+	}
+
+	if encrypted {
+		b.flags |= blockEncrypted
+	}
+
 	return
 }
 
+// writeBlob will write a value for a block as is
+func (t *Tree) writeBlob(b *Block, key, value []byte) (grew bool) {
+	checkValue(b)
+	valLen := int64(len(value))
+	switch {
+	case b.hasValue() && valLen == t.getValLen(b):
+		// Value length has not changed, we can write in place
+
+	case b.flags&blockInline != 0 && valLen <= t.getValCap(b):
+		// Value fits within the block, clear the remainder of the previous value and write in place
+		valueIndex := t.getValueIndex(b)
+		clear(t.bs[valueIndex+min(valLen, t.getValLen(b)) : valueIndex+t.getValLen(b)])
+		b.flags |= blockValue
+
+	case b.hasValue() && t.getOverflowOffset(b) != -1 && isOverflow(valLen) &&
+		getExtentSize(valLen) == getExtentSize(t.getValCap(b)):
+		// Value fits within the current overflow extent, clear the remainder of the previous value and write in place
+		valueIndex, prevLen := t.getOverflowOffset(b), t.getValLen(b)
+		clear(t.bs[valueIndex+min(valLen, prevLen) : valueIndex+prevLen])
+		t.setBlobSlot(b, blobValCap, valLen)
+
+	default:
+		offset := b.getOffset()
+		if grew = t.allocBlob(b, key, valLen, false); grew {
+			b = t.getBlock(offset)
+		}
+	}
+
+	copy(t.bs[t.getValueIndex(b):], value)
+	t.setValLen(b, valLen)
+	return
+}
+
+// growBlob will ensure a blob has the capacity to hold a value of the provided size. Capacity is doubled
+// until the size fits, the value length is left unchanged and all new capacity is zeroed.
 func (t *Tree) growBlob(b *Block, key []byte, sz int64) (grew bool) {
-	if sz <= b.valLen {
+	checkValue(b)
+	switch {
+	case sz > t.getValCap(b):
+	case b.hasValue():
+		return
+	case b.flags&blockInline != 0:
+		// Block was created with room for the value
+		b.flags |= blockValue
 		return
 	}
 
-	vlen := b.valLen
-	if vlen == 0 {
-		vlen = sz
+	vcap := t.getValCap(b)
+	if vcap == 0 {
+		vcap = sz
 	}
 
-	for vlen < sz {
-		vlen *= 2
+	for vcap < sz {
+		vcap *= 2
 	}
 
-	delta := vlen - b.valLen
+	return t.allocBlob(b, key, vcap, true)
+}
+
+// allocBlob will move a block's value into a new allocation with the provided capacity. When keep is
+// true the current value is copied over, all remaining capacity is zeroed and the previous allocation
+// is released.
+// Note: Capacities which meet the OverflowThreshold are stored in an overflow extent, leaving only the
+// key within the blob
+func (t *Tree) allocBlob(b *Block, key []byte, vcap int64, keep bool) (grew bool) {
+	offset := b.getOffset()
+	overflow := isOverflow(vcap)
+	keyLen := t.getStoredLen(b, key)
+	blobLen := keyLen
+	if !overflow {
+		blobLen += vcap
+	}
 
-	offset := b.offset
-	boffset := t.t.tail
-	blobLen := int64(len(key)) + vlen
-	if grew = t.grow(boffset + blobLen); grew {
+	boffset, grew := t.keyBlob(b, key, blobLen, overflow)
+	if grew {
 		b = t.getBlock(offset)
 	}
 
-	value := t.getValue(b)
-	copy(t.bs[boffset:], key)
-	copy(t.bs[boffset+b.keyLen:], value)
-	t.t.tail += blobLen
+	ooffset := int64(-1)
+	valueIndex := boffset + getBlobHeaderSize(b.flags) + keyLen
+	end := valueIndex + vcap
+	if overflow {
+		var ogrew bool
+		if ooffset, ogrew = t.allocOverflow(getExtentSize(vcap)); ogrew {
+			b = t.getBlock(offset)
+			grew = true
+		}
+
+		valueIndex = ooffset
+		end = ooffset + getExtentSize(vcap)
+	}
 
-	for i := t.t.tail - delta; i < t.t.tail; i++ {
-		t.bs[i] = 0
+	var n int64
+	if keep && b.hasValue() {
+		n = int64(copy(t.bs[valueIndex:valueIndex+vcap], t.getValue(b)))
 	}
 
-	b.blobOffset = boffset
-	b.valLen = vlen
+	clear(t.bs[valueIndex+n : end])
+
+	// Release the previous allocation
+	t.releaseBlob(b, boffset)
+	b.setBlobOffset(boffset)
+	t.setBlobSlot(b, blobOverflow, ooffset)
+	t.setBlobSlot(b, blobValLen, n)
+	t.setBlobSlot(b, blobValCap, vcap)
 	return
 }
 
-func (t *Tree) newBlock(key []byte) (b *Block, offset int64, grew bool) {
-	offset = t.t.tail
-	grew = t.grow(offset + BlockSize)
+// newBlock will create a block for a stored key, referencing the prefix at poffset (-1 for keys stored in full)
+func (t *Tree) newBlock(key []byte, vcap, poffset int64) (b *Block, offset int64, grew bool) {
+	keyLen := int64(len(key))
+	inline := isInline(keyLen, vcap)
+
+	var flags blockFlag
+	if t.t.format == FormatCompact {
+		flags |= blockCompact
+	}
+
+	if poffset != -1 {
+		flags |= blockPrefixed
+	}
+
+	// Blocks which are not inline only hold the offset of their blob after their slots
+	sz := getSlotSize(flags)
+	if inline {
+		flags |= blockInline
+		// Inline blocks always have room for a blob offset, so their value can be moved into a blob in place
+		vcap = max(vcap, getSlotSize(flags)-keyLen)
+		sz = keyLen + vcap
+	}
+
+	sz += getHeaderSize(flags)
+	offset, grew = t.alloc(sz)
 	b = t.getBlock(offset)
-	t.t.tail += BlockSize
 
 	// All new blocks start as red
 	b.c = colorRed
-	// Set offset and blob offset
-	b.offset = offset
-	b.blobOffset = -1
-	// Set parent and children to their zero values
-	b.parent = -1
-	b.children[0] = -1
-	b.children[1] = -1
-	// Set key length
-	b.keyLen = int64(len(key))
-	b.valLen = 0
+	// New blocks are considered root until they are attached to a parent
+	b.ct = childRoot
+	b.flags = flags
+	b.inlineKeyLen = 0
+	b.inlineValLen = 0
+	b.inlineValCap = 0
+	// Set offset, parent and children to their zero values
+	b.setOffset(offset)
+	b.setParent(-1)
+	b.setChild(0, -1)
+	b.setChild(1, -1)
+	if poffset != -1 {
+		b.setSlot(slotPrefix, poffset)
+	}
+
+	if !inline {
+		b.setBlobOffset(-1)
+		return
+	}
+
+	b.inlineKeyLen = uint8(keyLen)
+	b.inlineValCap = uint8(vcap)
+	keyOffset := t.getKeyOffset(b)
+	copy(t.bs[keyOffset:], key)
+	clear(t.bs[keyOffset+keyLen : offset+sz])
 	return
 }
 
-func (t *Tree) newBlob(key, value []byte) (offset int64, grew bool) {
-	offset = t.t.tail
-	blobLen := int64(len(key) + len(value))
-	grew = t.grow(offset + blobLen)
-	copy(t.bs[offset:], key)
-	copy(t.bs[offset+int64(len(key)):], value)
-	t.t.tail += blobLen
-	return
+// createBlock will return the offset of the Block matching the provided key, a new Block is created
+// if no match is found. New Blocks are not balanced until insertBalance is called.
+// Note: vcap is the expected capacity of the value, new Blocks which can fit their key and value within
+// InlineSize will hold them inline. A negative vcap will never be held inline.
+func (t *Tree) createBlock(key []byte, vcap int64) (offset int64) {
+	return t.createBlockFrom(t.getHeader().root, key, vcap)
 }
 
-// seekBlock will return a Block matching the provided key. It create is set to true, a new Block will be created if no match is found
-func (t *Tree) seekBlock(startOffset int64, key []byte, create bool) (offset int64, grew bool) {
-	offset = -1
-	if startOffset == -1 {
-		return
+// createBlockFrom will create a block for a key, searching for it's position from the provided block
+// Note: The key must belong within the subtree of the provided block
+func (t *Tree) createBlockFrom(startOffset int64, key []byte, vcap int64) (offset int64) {
+	// Find node whose key matches our provided key, if node does not exist - create it.
+	parent, ct := t.seekParent(startOffset, key)
+	if parent != -1 && ct == childRoot {
+		return parent
 	}
 
-	block := t.getBlock(startOffset)
-	blockKey := t.getKey(block)
+	// Keys sharing a prefix with their parent will only store the remainder of the key
+	poffset, plen := t.acquirePrefix(parent, key)
 
-	switch bytes.Compare(key, blockKey) {
-	case 1:
-		child := block.children[1]
-		if child == -1 {
-			if !create {
-				return
-			}
+	var nb *Block
+	nb, offset, _ = t.newBlock(key[plen:], vcap, poffset)
+	// The abbreviation is taken from the full key, as the stored key may be missing it's prefix
+	nb.abbr = getAbbr(key)
+	if parent == -1 {
+		// Root doesn't exist, our new block becomes the root
+		t.getHeader().root = offset
+		return
+	}
 
-			var nb *Block
-			if nb, offset, grew = t.newBlock(key); grew {
-				block = t.getBlock(startOffset)
-			}
+	t.setChild(t.getBlock(parent), nb, ct)
+	return
+}
 
-			nb.ct = childRight
-			nb.parent = startOffset
-			block.children[1] = offset
-			return
-		}
+// insertBalance will balance the tree after a new Block has been given it's blob
+func (t *Tree) insertBalance(b *Block) {
+	t.balance(b)
+	// Rotations may have moved the root, ensure our root reference is up to date
+	t.setRoot()
+	t.getHeader().cnt++
+	t.addBloom(b)
+}
 
-		return t.seekBlock(child, key, create)
+// seekBlock will return a Block matching the provided key, -1 is returned if no match is found
+func (t *Tree) seekBlock(startOffset int64, key []byte) (offset int64) {
+	if offset, ct := t.seekParent(startOffset, key); ct == childRoot {
+		return offset
+	}
 
-	case -1:
-		child := block.children[0]
-		if child == -1 {
-			if !create {
-				return
-			}
+	return -1
+}
 
-			var nb *Block
-			if nb, offset, grew = t.newBlock(key); grew {
-				block = t.getBlock(startOffset)
-			}
+// seekParent will return the Block matching the provided key with a child type of childRoot. If no
+// match is found, the Block which the key would be attached to is returned along with the side it
+// would be attached on.
+func (t *Tree) seekParent(startOffset int64, key []byte) (offset int64, ct childType) {
+	offset = startOffset
+	abbr := getAbbr(key)
+	for depth := 0; offset != -1; depth++ {
+		checkDepth(depth)
+		block := t.getBlock(offset)
+		child := int64(-1)
+		switch t.compareAbbr(key, abbr, block) {
+		case 1:
+			ct = childRight
+			child = block.getChild(1)
+		case -1:
+			ct = childLeft
+			child = block.getChild(0)
+		default:
+			return offset, childRoot
+		}
 
-			nb.ct = childLeft
-			nb.parent = startOffset
-			block.children[0] = offset
+		if child == -1 {
 			return
 		}
 
-		return t.seekBlock(child, key, create)
-
-	case 0:
-		offset = startOffset
-		return
+		offset = child
 	}
 
 	return
@@ -492,58 +1178,64 @@ func (t *Tree) grow(sz int64) (grew bool) {
 }
 
 func (t *Tree) balance(b *Block) {
-	parent := t.getBlock(b.parent)
-	uncle := t.getBlock(t.getUncle(b.offset))
+	for depth := 0; ; depth++ {
+		checkDepth(depth)
+		parent := t.getBlock(b.getParent())
+		uncle := t.getBlock(t.getUncle(b.getOffset()))
 
-	switch {
-	case b.c == colorBlack:
-		return
-	case b.ct == childRoot:
-		if b.c == colorRed {
+		switch {
+		case b.c == colorBlack:
+			return
+		case b.ct == childRoot:
 			b.c = colorBlack
 			return
-		}
 
-	case uncle != nil && uncle.c == colorRed:
-		parent.c = colorBlack
-		uncle.c = colorBlack
-
-		grandparent := t.getBlock(parent.parent)
-		grandparent.c = colorRed
-		// Balance grandparent
-		t.balance(grandparent)
-
-	case parent.c == colorRed:
-		grandparent := t.getBlock(parent.parent)
-
-		if t.isTriangle(b, parent) {
-			t.rotateParent(b)
-			// Balance parent
-			t.balance(parent)
-		} else {
-			// Is a line
-			t.rotateGrandparent(b)
+		case parent.c == colorBlack:
+			// Parent is black, our red block does not disrupt the tree
+			return
+
+		case uncle != nil && uncle.c == colorRed:
+			parent.c = colorBlack
+			uncle.c = colorBlack
+
+			grandparent := t.getBlock(parent.getParent())
+			grandparent.c = colorRed
 			// Balance grandparent
-			t.balance(grandparent)
+			b = grandparent
+
+		default:
+			// Parent is red
+			grandparent := t.getBlock(parent.getParent())
+
+			if t.isTriangle(b, parent) {
+				t.rotateParent(b)
+				// Balance parent
+				b = parent
+			} else {
+				// Is a line
+				t.rotateGrandparent(b)
+				// Balance grandparent
+				b = grandparent
+			}
 		}
 	}
 }
 
 func (t *Tree) leftRotate(b *Block) {
-	parent := t.getBlock(b.parent)
-	grandparent := t.getBlock(parent.parent)
+	parent := t.getBlock(b.getParent())
+	grandparent := t.getBlock(parent.getParent())
 
 	// Swap  children
-	swapChild := t.getBlock(b.children[0])
-	b.children[0] = parent.offset
+	swapChild := t.getBlock(b.getChild(0))
+	b.setChild(0, parent.getOffset())
 
 	if swapChild != nil {
-		parent.children[1] = swapChild.offset
-		swapChild.parent = parent.offset
+		parent.setChild(1, swapChild.getOffset())
+		swapChild.setParent(parent.getOffset())
 		swapChild.ct = childRight
 		// Set nidx as the child for our grandparent
 	} else {
-		parent.children[1] = -1
+		parent.setChild(1, -1)
 	}
 
 	// Set block as the child for our grandparent
@@ -551,12 +1243,12 @@ func (t *Tree) leftRotate(b *Block) {
 
 	// Set n's grandparent as parent
 	if grandparent == nil {
-		b.parent = -1
+		b.setParent(-1)
 	} else {
-		b.parent = grandparent.offset
+		b.setParent(grandparent.getOffset())
 	}
 	// Set n as parent to the original parent
-	parent.parent = b.offset
+	parent.setParent(b.getOffset())
 
 	// Set child types
 	b.ct = parent.ct
@@ -564,19 +1256,19 @@ func (t *Tree) leftRotate(b *Block) {
 }
 
 func (t *Tree) rightRotate(b *Block) {
-	parent := t.getBlock(b.parent)
-	grandparent := t.getBlock(parent.parent)
+	parent := t.getBlock(b.getParent())
+	grandparent := t.getBlock(parent.getParent())
 
 	// Swap  children
-	swapChild := t.getBlock(b.children[1])
-	b.children[1] = parent.offset
+	swapChild := t.getBlock(b.getChild(1))
+	b.setChild(1, parent.getOffset())
 
 	if swapChild != nil {
-		parent.children[0] = swapChild.offset
-		swapChild.parent = parent.offset
+		parent.setChild(0, swapChild.getOffset())
+		swapChild.setParent(parent.getOffset())
 		swapChild.ct = childLeft
 	} else {
-		parent.children[0] = -1
+		parent.setChild(0, -1)
 		// Set nidx as the child for our grandparent
 	}
 
@@ -585,12 +1277,12 @@ func (t *Tree) rightRotate(b *Block) {
 
 	// Set n's grandparent as parent
 	if grandparent == nil {
-		b.parent = -1
+		b.setParent(-1)
 	} else {
-		b.parent = grandparent.offset
+		b.setParent(grandparent.getOffset())
 	}
 	// Set n as parent to the original parent
-	parent.parent = b.offset
+	parent.setParent(b.getOffset())
 
 	// Set child types
 	b.ct = parent.ct
@@ -611,8 +1303,8 @@ func (t *Tree) rotateParent(b *Block) {
 }
 
 func (t *Tree) rotateGrandparent(b *Block) {
-	parent := t.getBlock(b.parent)
-	grandparent := t.getBlock(parent.parent)
+	parent := t.getBlock(b.getParent())
+	grandparent := t.getBlock(parent.getParent())
 
 	switch parent.ct {
 	case childLeft:
@@ -646,214 +1338,285 @@ func (t *Tree) isTriangle(b, parent *Block) (isTriangle bool) {
 }
 
 func (t *Tree) iterate(b *Block, fn ForEachFn) (ended bool) {
-	if child := b.children[0]; child != -1 {
-		if ended = t.iterate(t.getBlock(child), fn); ended {
+	var stack [maxDepth]int64
+	var n int
+	for offset := b.getOffset(); offset != -1 || n > 0; {
+		// Descend to the left-most block, tracking the path so we can return to it
+		for ; offset != -1; offset = t.getBlock(offset).getChild(0) {
+			checkDepth(n)
+			stack[n] = offset
+			n++
+		}
+
+		n--
+		b = t.getBlock(stack[n])
+		offset = b.getChild(1)
+		if ended = fn(t.getKey(b), t.readValue(b)); ended {
 			return
 		}
 	}
 
-	if ended = fn(t.getKey(b), t.getValue(b)); ended {
-		return
+	return
+}
+
+func (t *Tree) iterateKeys(b *Block, fn ForEachKeyFn) (ended bool) {
+	var stack [maxDepth]int64
+	var n int
+	for offset := b.getOffset(); offset != -1 || n > 0; {
+		// Descend to the left-most block, tracking the path so we can return to it
+		for ; offset != -1; offset = t.getBlock(offset).getChild(0) {
+			checkDepth(n)
+			stack[n] = offset
+			n++
+		}
+
+		n--
+		b = t.getBlock(stack[n])
+		offset = b.getChild(1)
+		if ended = fn(t.getKey(b)); ended {
+			return
+		}
 	}
 
-	if child := b.children[1]; child != -1 {
-		if ended = t.iterate(t.getBlock(child), fn); ended {
+	return
+}
+
+// iterateRange will iterate through each item within the range of [start, end)
+// Note: A nil start or end will leave that side of the range unbounded
+func (t *Tree) iterateRange(start, end []byte, fn ForEachFn) (ended bool) {
+	for offset := t.seekStart(start); offset != -1; offset = t.getNext(offset) {
+		b := t.getBlock(offset)
+		key := t.getKey(b)
+		if end != nil && bytes.Compare(key, end) != -1 {
 			return
 		}
 
+		if ended = fn(key, t.readValue(b)); ended {
+			return
+		}
 	}
 
 	return
 }
 
-// detachFromParent will detach a block from it's parent reference
-// Note: This is never called on root node, parent will always exist
-func (t *Tree) detachFromParent(b *Block) {
-	// Get the parent of block
-	parent := t.getBlock(b.parent)
-	if parent == nil {
-		return
+// getNext will get the item directly following a given node
+func (t *Tree) getNext(startOffset int64) (offset int64) {
+	b := t.getBlock(startOffset)
+	if child := b.getChild(1); child != -1 {
+		return t.getHead(child)
+	}
+
+	// Walk up until we arrive from a left child
+	for depth := 0; b.ct == childRight; depth++ {
+		checkDepth(depth)
+		b = t.getBlock(b.getParent())
 	}
 
-	// Set parent's child value for -1 where the block resided
-	if b.ct == childLeft {
-		parent.children[0] = -1
-	} else if b.ct == childRight {
-		parent.children[1] = -1
+	if b.ct == childRoot {
+		// We've walked up from the right-most item, nothing follows
+		return -1
 	}
+
+	return b.getParent()
 }
 
-func (t *Tree) zeroChildrenDelete(b, parent *Block) {
-	t.replace(b, nil, parent)
+// seekStart will return the first Block whose key is greater than or equal to start
+// Note: A nil start will return the very first Block
+func (t *Tree) seekStart(start []byte) (offset int64) {
+	if start == nil {
+		return t.getHead(t.getHeader().root)
+	}
+
+	return t.seekCeiling(t.getHeader().root, start)
 }
 
-func (t *Tree) oneChildDelete(b, parent *Block) (next *Block) {
-	if b.children[1] != -1 {
-		next = t.getBlock(b.children[1])
-	} else {
-		next = t.getBlock(b.children[0])
+// seekCeiling will return the first Block whose key is greater than or equal to the provided key
+func (t *Tree) seekCeiling(startOffset int64, key []byte) (offset int64) {
+	offset = -1
+	for depth, cur := 0, startOffset; cur != -1; depth++ {
+		checkDepth(depth)
+		block := t.getBlock(cur)
+		switch t.compareKey(key, block) {
+		case 1:
+			cur = block.getChild(1)
+		case -1:
+			// This block is the ceiling unless a smaller match exists within the left branch
+			offset = cur
+			cur = block.getChild(0)
+		default:
+			return cur
+		}
 	}
 
-	t.replace(b, next, parent)
 	return
 }
 
-func (t *Tree) twoChildDelete(b, parent *Block) (next *Block) {
-	var child *Block
-	// Get the very next element following block
-	// Note: Selecting the second child will ensure we move forward.
-	// Calling getHead from this location will land us at the item directly
-	// following the target block.
-	next = t.getBlock(t.getHead(b.children[1]))
-
-	if next.offset != b.children[1] {
-		// Our next item is not the direct child of our target block, let's have our next block's parent adopt the orphan
-		// Note: If the child doesn't exist, the offset of -1 will be applied as the orphan reference
-		var coffset int64 = -1
-		if child = t.getBlock(next.children[1]); child != nil {
-			// Child exists, set child offset
-			coffset = child.offset
-			child.parent = next.parent
+func (t *Tree) deleteFunc(offset int64, end []byte, fn DeleteFn) (n int) {
+	for offset != -1 {
+		b := t.getBlock(offset)
+		key := t.getKey(b)
+		if end != nil && bytes.Compare(key, end) != -1 {
+			return
 		}
 
-		nextParent := t.getBlock(next.parent)
-		// Have next parent adopt the orphan
-		nextParent.children[0] = coffset
-		// Detach next from it's parent
-		next.parent = -1
+		// Acquire the next offset before deleting, deleteBlock will not move any remaining blocks
+		next := t.getNext(offset)
+		if fn(key, t.readValue(b)) {
+			t.deleteBlock(b)
+			n++
+		}
 
-		next.children[1] = b.children[1]
-		if child = t.getBlock(next.children[1]); child != nil {
-			child.parent = next.offset
+		offset = next
+	}
+
+	return
+}
+
+// deleteBlock will remove a block from the tree and rebalance
+func (t *Tree) deleteBlock(b *Block) {
+	if b.getChild(0) != -1 && b.getChild(1) != -1 {
+		// Block has two children, swap positions with the item directly following it.
+		// Note: The blocks themselves are moved (rather than their contents) so the offsets
+		// of all remaining items stay intact.
+		t.swapBlocks(b, t.getBlock(t.getHead(b.getChild(1))))
+	}
+
+	// Block has at most one child at this point
+	child := t.getBlock(b.getChild(0))
+	if child == nil {
+		child = t.getBlock(b.getChild(1))
+	}
+
+	if child != nil {
+		// A block with a single child is always black and it's child is always red. Replacing
+		// the block with it's child and painting the child black will retain the black-level
+		t.replace(b, child, t.getBlock(b.getParent()))
+		child.c = colorBlack
+	} else {
+		if b.c == colorBlack {
+			// Removing a black leaf will disrupt the black-level, balance while the block is still in place
+			t.deleteBalance(b)
 		}
+
+		t.replace(b, nil, t.getBlock(b.getParent()))
+	}
+
+	t.setRoot()
+	t.getHeader().cnt--
+
+	// Release the blob and block
+	t.freeBlock(b)
+}
+
+// swapBlocks will swap the tree positions of a block and the item directly following it
+func (t *Tree) swapBlocks(b, next *Block) {
+	left := t.getBlock(b.getChild(0))
+	right := t.getBlock(b.getChild(1))
+	nextParent := t.getBlock(next.getParent())
+	orphan := t.getBlock(next.getChild(1))
+
+	// Next takes the place of block
+	t.replace(b, next, t.getBlock(b.getParent()))
+	next.setChild(0, left.getOffset())
+	left.setParent(next.getOffset())
+
+	if nextParent.getOffset() == b.getOffset() {
+		// Next was our direct child, block becomes the right child of next
+		next.setChild(1, b.getOffset())
+		b.setParent(next.getOffset())
+		b.ct = childRight
+	} else {
+		next.setChild(1, right.getOffset())
+		right.setParent(next.getOffset())
+		nextParent.setChild(0, b.getOffset())
+		b.setParent(nextParent.getOffset())
+		b.ct = childLeft
 	}
 
-	next.children[0] = b.children[0]
-	if child = t.getBlock(next.children[0]); child != nil {
-		child.parent = next.offset
+	// Block takes the place of next, the only child next could have had is a right child
+	b.setChild(0, -1)
+	b.setChild(1, -1)
+	if orphan != nil {
+		b.setChild(1, orphan.getOffset())
+		orphan.setParent(b.getOffset())
 	}
 
-	t.replace(b, next, parent)
-	return
+	b.c, next.c = next.c, b.c
 }
 
+// replace will have new take the position of old within the tree
 func (t *Tree) replace(old, new, parent *Block) {
 	var noffset int64 = -1
 	if new != nil {
-		t.detachFromParent(new)
 		// Set next-block childtype as the block childtype
 		new.ct = old.ct
 		// Set the next-block parent as the block parent
-		new.parent = old.parent
-		noffset = new.offset
+		new.setParent(old.getParent())
+		noffset = new.getOffset()
 	}
 
 	// Set the parent's child value as the offset to our next block
 	switch old.ct {
 	case childRoot:
-		// If block is root, we need to update the trunk's reference to root
-		t.t.root = noffset
+		// If block is root, we need to update the header's reference to root
+		t.getHeader().root = noffset
 	case childLeft:
-		parent.children[0] = noffset
+		parent.setChild(0, noffset)
 	case childRight:
-		parent.children[1] = noffset
+		parent.setChild(1, noffset)
 	}
 }
 
-func (t *Tree) deleteBalance(b, parent *Block) {
-	if b.c != colorDoubleBlack {
-		return
-	}
-
-	if b.ct == childRoot {
-		b.c = colorBlack
-		return
-	}
-
-	var sibling *Block
-	// Acquire sibling
-	switch {
-	case b.ct == childLeft:
-		sibling = t.getBlock(parent.children[1])
-	case b.ct == childRight:
-		sibling = t.getBlock(parent.children[0])
-	case b.ct == childRoot:
-		b.c = colorBlack
-		return
-	}
+// deleteBalance will restore the black-level for a black block which is about to be removed
+// Note: The block is still in place when this is called, which allows it to stand in for the empty leaf
+func (t *Tree) deleteBalance(b *Block) {
+	for depth := 0; b.ct != childRoot; depth++ {
+		checkDepth(depth)
+		parent := t.getBlock(b.getParent())
+		sibling := t.getSibling(b)
+		if sibling.c == colorRed {
+			// Rotate the red sibling above our parent so that we are left with a black sibling
+			sibling.c = colorBlack
+			parent.c = colorRed
+			t.rotateParent(sibling)
+			sibling = t.getSibling(b)
+		}
 
-	// Set sibling black state
-	// Note: We can eventually remove this for performance reasons once this function
-	// is completely fleshed out. We need to ensure that we are not dealing with a nil sibling
-	// This is just to avoid running into panic land
-	siblingIsBlack := isBlack(sibling)
-	var leftNephew, rightNephew *Block
-	if sibling != nil {
-		leftNephew = t.getBlock(sibling.children[0])
-		rightNephew = t.getBlock(sibling.children[1])
-	}
+		// Acquire nephews, near is the nephew which sits closest to block
+		near := t.getBlock(sibling.getChild(0))
+		far := t.getBlock(sibling.getChild(1))
+		if b.ct == childRight {
+			near, far = far, near
+		}
 
-	// Sibling rotate bonanza
-	switch {
-	// Sibling is black and has both black children
-	case siblingIsBlack && (isBlack(leftNephew) && isBlack(rightNephew)):
-		if sibling != nil {
+		if isBlack(near) && isBlack(far) {
+			// Sibling has both black children
 			sibling.c = colorRed
-		}
+			if parent.c == colorRed {
+				parent.c = colorBlack
+				return
+			}
 
-		if parent.c == colorRed {
-			parent.c = colorBlack
-			return
-		} else if parent.c == colorBlack {
-			parent.c = colorDoubleBlack
-			t.deleteBalance(parent, t.getBlock(parent.parent))
+			// Parent is now short a black level, push the problem up
+			b = parent
+			continue
 		}
 
-	// Sibling is black and has at least one red child
-	case siblingIsBlack:
-		// Rotation cases:
-		switch {
-		// 1. Left Left Case (s is left child of its parent and r is left child of s or both children of s are red).
-		case sibling.ct == childLeft && isRed(leftNephew):
-			// Right rotate sibling
-			t.rightRotate(sibling)
-			// Recolor left nephew to black
-			leftNephew.c = colorBlack
-
-		// 2. Left Right Case (s is left child of its parent and r is right child).
-		case sibling.ct == childLeft && isRed(rightNephew):
-			// Left rotate right nephew
-			t.leftRotate(rightNephew)
-			// Right rotate right nephew
-			t.rightRotate(rightNephew)
-
-			// Note: Sibling is now left nephew and right nephew is now sibling
-
-		// 3. Right Right Case (s is right child of its parent and r is right child of s or both children of s are red)
-		case sibling.ct == childRight && isRed(rightNephew):
-			// Left rotate sibling
-			t.leftRotate(sibling)
-			// Recolor right nephew to black
-			rightNephew.c = colorBlack
-
-		// 4. Right Left Case (s is right child of its parent and r is left child of s)
-		case sibling.ct == childRight && isRed(leftNephew):
-			// Right rotate left nephew
-			t.rightRotate(leftNephew)
-			// Left rotate left nephew
-			t.leftRotate(leftNephew)
-
-			// Note: Sibling is now right nephew and left nephew is now sibling
+		if isBlack(far) {
+			// Near nephew is red, rotate it above the sibling so the red nephew is on the far side
+			near.c = colorBlack
+			sibling.c = colorRed
+			t.rotateParent(near)
+			far, sibling = sibling, near
 		}
 
-	// Sibling is red
-	default:
-		if sibling.ct == childLeft {
-			t.rightRotate(sibling)
-		} else if sibling.ct == childRight {
-			t.leftRotate(sibling)
-		}
+		// Rotate sibling above our parent and take on the parent's color
+		sibling.c = parent.c
+		parent.c = colorBlack
+		far.c = colorBlack
+		t.rotateParent(sibling)
+		return
 	}
 
-	b.c = colorBlack
+	// Every path has lost a black level, we are balanced
 }
@@ -2,11 +2,15 @@ package rbt
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"math/rand"
 	"os"
 	"strconv"
 	"testing"
+	"unsafe"
 
+	"github.com/itsmontoya/rbt/backend"
 	"github.com/itsmontoya/rbt/testUtils"
 
 	"github.com/missionMeteora/journaler"
@@ -78,6 +82,342 @@ func TestDelete(t *testing.T) {
 	}
 }
 
+func TestRandomDelete(t *testing.T) {
+	w := New(1024)
+	tm := make(map[string][]byte)
+	for i := 0; i < 5000; i++ {
+		key := []byte(fmt.Sprintf("%04d", rand.Intn(1000)))
+		if rand.Intn(3) == 0 {
+			w.Delete(key)
+			delete(tm, string(key))
+		} else {
+			w.Put(key, key)
+			tm[string(key)] = key
+		}
+
+		if err := testValidate(w); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for key, mv := range tm {
+		if val := w.Get([]byte(key)); !bytes.Equal(val, mv) {
+			t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", string(mv), string(val))
+		}
+	}
+}
+
+func TestDeleteFunc(t *testing.T) {
+	w := New(1024)
+	for i := 0; i < 1000; i++ {
+		key := []byte(fmt.Sprintf("%04d", i))
+		w.Put(key, []byte(strconv.Itoa(i%3)))
+	}
+
+	n := w.DeleteFunc(func(key, val []byte) (del bool) {
+		return string(val) == "0"
+	})
+
+	if n != 334 {
+		t.Fatalf("invalid number of deletions, expected %d and received %d", 334, n)
+	}
+
+	if err := testValidate(w); err != nil {
+		t.Fatal(err)
+	}
+
+	var cnt int
+	w.ForEach(func(key, val []byte) (end bool) {
+		if string(val) == "0" {
+			t.Fatalf("invalid value, expected \"%s\" to be deleted", string(key))
+		}
+
+		cnt++
+		return
+	})
+
+	if cnt != 666 || w.Len() != 666 {
+		t.Fatalf("invalid length, expected %d and received %d (%d)", 666, cnt, w.Len())
+	}
+}
+
+func TestDeleteRangeFunc(t *testing.T) {
+	w := New(1024)
+	for i := 0; i < 1000; i++ {
+		key := []byte(fmt.Sprintf("%04d", i))
+		w.Put(key, key)
+	}
+
+	n := w.DeleteRangeFunc([]byte("0100"), []byte("0200"), func(key, val []byte) (del bool) {
+		return true
+	})
+
+	if n != 100 {
+		t.Fatalf("invalid number of deletions, expected %d and received %d", 100, n)
+	}
+
+	if err := testValidate(w); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("%04d", i)
+		val := string(w.Get([]byte(key)))
+		if i >= 100 && i < 200 {
+			if len(val) != 0 {
+				t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "", val)
+			}
+		} else if val != key {
+			t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", key, val)
+		}
+	}
+
+	// Unbounded start with a start key which does not exist
+	if n = w.DeleteRangeFunc(nil, []byte("0050x"), func(key, val []byte) (del bool) {
+		return true
+	}); n != 51 {
+		t.Fatalf("invalid number of deletions, expected %d and received %d", 51, n)
+	}
+
+	// Unbounded end with a start key which does not exist
+	if n = w.DeleteRangeFunc([]byte("0899x"), nil, func(key, val []byte) (del bool) {
+		return true
+	}); n != 100 {
+		t.Fatalf("invalid number of deletions, expected %d and received %d", 100, n)
+	}
+
+	if w.Len() != 749 {
+		t.Fatalf("invalid length, expected %d and received %d", 749, w.Len())
+	}
+}
+
+func TestDeleteRange(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		w := New(1024)
+		tm := make(map[string][]byte)
+		for j := 0; j < 500; j++ {
+			key := []byte(fmt.Sprintf("%04d", rand.Intn(1000)))
+			w.Put(key, key)
+			tm[string(key)] = key
+		}
+
+		start := []byte(fmt.Sprintf("%04d", rand.Intn(1000)))
+		end := []byte(fmt.Sprintf("%04d", rand.Intn(1000)))
+		switch i {
+		case 0:
+			start = nil
+		case 1:
+			end = nil
+		case 2:
+			start, end = nil, nil
+		}
+
+		var expected int
+		for key := range tm {
+			if start != nil && key < string(start) {
+				continue
+			}
+
+			if end != nil && key >= string(end) {
+				continue
+			}
+
+			delete(tm, key)
+			expected++
+		}
+
+		if n := w.DeleteRange(start, end); n != expected {
+			t.Fatalf("invalid number of deletions, expected %d and received %d", expected, n)
+		}
+
+		if err := testValidate(w); err != nil {
+			t.Fatal(err)
+		}
+
+		var cnt int
+		w.ForEach(func(key, val []byte) (end bool) {
+			if !bytes.Equal(val, tm[string(key)]) {
+				t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", string(tm[string(key)]), string(val))
+			}
+
+			cnt++
+			return
+		})
+
+		if cnt != len(tm) || w.Len() != len(tm) {
+			t.Fatalf("invalid length, expected %d and received %d (%d)", len(tm), cnt, w.Len())
+		}
+	}
+}
+
+func TestDeleteReuse(t *testing.T) {
+	w := New(1024)
+	val := bytes.Repeat([]byte("v"), 32)
+	for i := 0; i < 1000; i++ {
+		w.Put([]byte(fmt.Sprintf("tenant/1/%04d", i)), val)
+	}
+
+	// Add a trailing entry so freed space cannot simply be reclaimed by pulling back the tail
+	w.Put([]byte("tenant/2/0000"), val)
+	size := w.Size()
+
+	if n := w.DeleteRange([]byte("tenant/1/"), []byte("tenant/10")); n != 1000 {
+		t.Fatalf("invalid number of deletions, expected %d and received %d", 1000, n)
+	}
+
+	for i := 0; i < 1000; i++ {
+		w.Put([]byte(fmt.Sprintf("tenant/3/%04d", i)), val)
+	}
+
+	if w.Size() != size {
+		t.Fatalf("invalid size, expected %d and received %d", size, w.Size())
+	}
+
+	if err := testValidate(w); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestForEachKey(t *testing.T) {
+	w := New(1024)
+	for i := 0; i < 100; i++ {
+		key := []byte(fmt.Sprintf("%03d", i))
+		w.Put(key, key)
+	}
+
+	var cnt int
+	w.ForEachKey(func(key []byte) (end bool) {
+		if expected := fmt.Sprintf("%03d", cnt); string(key) != expected {
+			t.Fatalf("invalid key, expected \"%s\" and received \"%s\"", expected, string(key))
+		}
+
+		cnt++
+		return cnt == 50
+	})
+
+	if cnt != 50 {
+		t.Fatalf("invalid number of iterations, expected %d and received %d", 50, cnt)
+	}
+
+	keys := w.AppendKeys(make([][]byte, 0, w.Len()))
+	if len(keys) != 100 {
+		t.Fatalf("invalid number of keys, expected %d and received %d", 100, len(keys))
+	}
+
+	for i, key := range keys {
+		if expected := fmt.Sprintf("%03d", i); string(key) != expected {
+			t.Fatalf("invalid key, expected \"%s\" and received \"%s\"", expected, string(key))
+		}
+	}
+}
+
+func TestPutIfAbsent(t *testing.T) {
+	w := New(1024)
+	if !w.PutIfAbsent([]byte("leader"), []byte("node-1")) {
+		t.Fatal("invalid response, expected the item to be inserted")
+	}
+
+	if w.PutIfAbsent([]byte("leader"), []byte("node-2")) {
+		t.Fatal("invalid response, expected the item to not be inserted")
+	}
+
+	if val := string(w.Get([]byte("leader"))); val != "node-1" {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "node-1", val)
+	}
+
+	if w.Len() != 1 {
+		t.Fatalf("invalid length, expected %d and received %d", 1, w.Len())
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	w := New(1024)
+	if w.CompareAndSwap([]byte("leader"), nil, []byte("node-1")) {
+		t.Fatal("invalid response, expected missing key to not be swapped")
+	}
+
+	w.Put([]byte("leader"), []byte("node-1"))
+	if w.CompareAndSwap([]byte("leader"), []byte("node-2"), []byte("node-3")) {
+		t.Fatal("invalid response, expected mismatched value to not be swapped")
+	}
+
+	if !w.CompareAndSwap([]byte("leader"), []byte("node-1"), []byte("node-10")) {
+		t.Fatal("invalid response, expected the value to be swapped")
+	}
+
+	if val := string(w.Get([]byte("leader"))); val != "node-10" {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "node-10", val)
+	}
+
+	if w.CompareAndDelete([]byte("leader"), []byte("node-1")) {
+		t.Fatal("invalid response, expected mismatched value to not be deleted")
+	}
+
+	if !w.CompareAndDelete([]byte("leader"), []byte("node-10")) {
+		t.Fatal("invalid response, expected the item to be deleted")
+	}
+
+	if val := w.Get([]byte("leader")); val != nil {
+		t.Fatalf("invalid value, expected nil and received \"%s\"", string(val))
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	w := New(1024)
+	w.Put([]byte("neighbor"), []byte("untouched"))
+
+	incr := func(old []byte, exists bool) (new []byte, del bool) {
+		n, _ := strconv.Atoi(string(old))
+		return []byte(strconv.Itoa(n + 1)), false
+	}
+
+	for i := 0; i < 100; i++ {
+		w.Upsert([]byte("counter"), incr)
+	}
+
+	if val := string(w.Get([]byte("counter"))); val != "100" {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "100", val)
+	}
+
+	// Appending to the old value should never write into neighboring data
+	w.Upsert([]byte("counter"), func(old []byte, exists bool) (new []byte, del bool) {
+		return append(old, "!"...), false
+	})
+
+	if val := string(w.Get([]byte("counter"))); val != "100!" {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "100!", val)
+	}
+
+	if val := string(w.Get([]byte("neighbor"))); val != "untouched" {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "untouched", val)
+	}
+
+	// Deleting a missing key should leave the tree untouched
+	w.Upsert([]byte("missing"), func(old []byte, exists bool) (new []byte, del bool) {
+		if exists {
+			t.Fatal("invalid exists value, expected false")
+		}
+
+		return nil, true
+	})
+
+	if w.Len() != 2 {
+		t.Fatalf("invalid length, expected %d and received %d", 2, w.Len())
+	}
+
+	w.Upsert([]byte("counter"), func(old []byte, exists bool) (new []byte, del bool) {
+		return nil, true
+	})
+
+	if val := w.Get([]byte("counter")); val != nil || w.Len() != 1 {
+		t.Fatalf("invalid value, expected nil and received \"%s\" (%d)", string(val), w.Len())
+	}
+
+	if err := testValidate(w); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestGrow(t *testing.T) {
 	w := New(1024)
 	k := []byte("hello")
@@ -95,6 +435,296 @@ func TestGrow(t *testing.T) {
 	}
 }
 
+func TestAppend(t *testing.T) {
+	w := New(1024)
+	w.Put([]byte("neighbor"), []byte("untouched"))
+
+	var expected []byte
+	for i := 0; i < 1000; i++ {
+		event := []byte(fmt.Sprintf("event-%d;", i))
+		w.Append([]byte("events"), event)
+		expected = append(expected, event...)
+	}
+
+	if val := w.Get([]byte("events")); !bytes.Equal(val, expected) {
+		t.Fatalf("invalid value, expected %d bytes and received %d bytes", len(expected), len(val))
+	}
+
+	if val := string(w.Get([]byte("neighbor"))); val != "untouched" {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "untouched", val)
+	}
+
+	if w.Len() != 2 {
+		t.Fatalf("invalid length, expected %d and received %d", 2, w.Len())
+	}
+
+	// Capacity is doubled, so appends should rarely need a new blob
+	size := w.Size()
+	w.Append([]byte("events"), []byte("x"))
+	if w.Size() != size {
+		t.Fatalf("invalid size, expected %d and received %d", size, w.Size())
+	}
+
+	// Appending a value from the tree to itself
+	w.Append([]byte("copy"), w.Get([]byte("neighbor")))
+	w.Append([]byte("copy"), w.Get([]byte("copy")))
+	if val := string(w.Get([]byte("copy"))); val != "untoucheduntouched" {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "untoucheduntouched", val)
+	}
+
+	w.Put([]byte("events"), []byte("reset"))
+	if val := string(w.Get([]byte("events"))); val != "reset" {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "reset", val)
+	}
+}
+
+func TestInline(t *testing.T) {
+	w := New(1024)
+	w.Put([]byte("flag"), []byte("on"))
+	w.Incr([]byte("counter"), 1)
+
+	// Inline keys and values are padded to fit a blob offset, "flagon" is shorter than a slot
+	if size := TrunkSize + 2*getHeaderSize(0) + wideSlotSize + int64(len("counter")+CounterSize); w.Size() != size {
+		t.Fatalf("invalid size, expected %d and received %d", size, w.Size())
+	}
+
+	offset := w.seekBlock(w.getHeader().root, []byte("flag"))
+	if b := w.getBlock(offset); b.flags&blockInline == 0 || w.getKeyOffset(b) != offset+getHeaderSize(0) {
+		t.Fatalf("invalid block, expected an inline block and received a key offset of %d", w.getKeyOffset(b))
+	}
+
+	// Values which fit within the block are written in place
+	w.Put([]byte("flag"), []byte("n"))
+	if val := string(w.Get([]byte("flag"))); val != "n" {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "n", val)
+	}
+
+	// Values which outgrow the block are moved into a blob
+	large := bytes.Repeat([]byte("off"), InlineSize)
+	w.Put([]byte("flag"), large)
+	if b := w.getBlock(offset); b.flags&blockInline != 0 {
+		t.Fatal("invalid block, expected the value to be moved out of the block")
+	}
+
+	if val := w.Get([]byte("flag")); !bytes.Equal(val, large) {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", large, val)
+	}
+
+	if n := w.Incr([]byte("counter"), 1); n != 2 {
+		t.Fatalf("invalid counter, expected %d and received %d", 2, n)
+	}
+
+	// Inline blocks are released along with their keys and values
+	size := w.Size()
+	w.Delete([]byte("counter"))
+	w.Put([]byte("number"), []byte("20"))
+	if w.Size() != size {
+		t.Fatalf("invalid size, expected %d and received %d", size, w.Size())
+	}
+}
+
+func TestCompact(t *testing.T) {
+	if _, err := NewRawFormat(1024, backend.NewBytes(), Format(9)); err != ErrInvalidFormat {
+		t.Fatalf("invalid error, expected %v and received %v", ErrInvalidFormat, err)
+	}
+
+	bs := backend.NewBytes()
+	w, err := NewRawFormat(1024, bs, FormatCompact)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.SetPrefixCompression(true)
+	large := bytes.Repeat([]byte("large"), OverflowThreshold)
+	appended := bytes.Repeat([]byte("appended"), InlineSize)
+	for i := 0; i < 1000; i++ {
+		key := []byte(fmt.Sprintf("compact/%04d", i))
+		if i%100 == 0 {
+			w.Put(key, large)
+			continue
+		}
+
+		w.Put(key, key)
+	}
+
+	// Inline values which outgrow their block are moved into a blob
+	w.Append([]byte("compact/0001"), appended)
+	w.DeleteRange([]byte("compact/0500"), []byte("compact/0600"))
+	if err = testValidate(w); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		key := []byte(fmt.Sprintf("compact/%04d", i))
+		expected := key
+		switch {
+		case i >= 500 && i < 600:
+			expected = nil
+		case i == 1:
+			expected = append(key[:len(key):len(key)], appended...)
+		case i%100 == 0:
+			expected = large
+		}
+
+		if val := w.Get(key); !bytes.Equal(val, expected) {
+			t.Fatalf("invalid value for \"%s\", expected %d bytes and received %d", key, len(expected), len(val))
+		}
+	}
+
+	// Existing trees are opened using their recorded format
+	r, err := NewRaw(1024, bs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Put([]byte("reopened"), []byte("value"))
+	b := r.getBlock(r.seekBlock(r.getHeader().root, []byte("reopened")))
+	if b.flags&blockCompact == 0 || string(r.Get([]byte("compact/0002"))) != "compact/0002" {
+		t.Fatal("invalid tree, expected the compact format to be retained")
+	}
+
+	if wide, compact := getHeaderSize(0), getHeaderSize(blockCompact); compact >= wide {
+		t.Fatalf("invalid header size, expected less than %d and received %d", wide, compact)
+	}
+}
+
+func TestCorruptTree(t *testing.T) {
+	// Point the right-most block back at the root to form a cycle of children
+	childCycle := func(w *Tree) {
+		tail := w.getBlock(w.getTail(w.getHeader().root))
+		tail.setChild(1, w.getHeader().root)
+	}
+
+	// Point the left-most block back at the root, so the cycle is reached before any block is released
+	headCycle := func(w *Tree) {
+		head := w.getBlock(w.getHead(w.getHeader().root))
+		head.setChild(0, w.getHeader().root)
+	}
+
+	// Point the root at itself as it's own parent to form a cycle of parents
+	parentCycle := func(ct childType) func(*Tree) {
+		return func(w *Tree) {
+			root := w.getBlock(w.getHeader().root)
+			root.ct = ct
+			root.setParent(root.getOffset())
+		}
+	}
+
+	tests := []struct {
+		name    string
+		corrupt func(*Tree)
+		fn      func(*Tree)
+	}{
+		{"get", childCycle, func(w *Tree) { w.Get([]byte("zzz")) }},
+		{"delete range", childCycle, func(w *Tree) { w.DeleteRange([]byte("zzz"), nil) }},
+		{"reset bucket", headCycle, func(w *Tree) { w.Bucket([]byte("bucket")).Reset() }},
+		{"range", parentCycle(childRight), func(w *Tree) {
+			for range w.All() {
+			}
+		}},
+		{"backward", parentCycle(childLeft), func(w *Tree) {
+			for range w.Backward() {
+			}
+		}},
+		{"put", parentCycle(childRight), func(w *Tree) { w.Put([]byte("new"), []byte("value")) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := New(1024)
+			b, _ := w.CreateBucket([]byte("bucket"))
+			for i := 0; i < 100; i++ {
+				w.Put([]byte(strconv.Itoa(i)), []byte("value"))
+				b.Put([]byte(strconv.Itoa(i)), []byte("value"))
+			}
+
+			if tt.name == "reset bucket" {
+				tt.corrupt(b)
+			} else {
+				tt.corrupt(w)
+			}
+
+			defer func() {
+				if err := recover(); err != ErrCorruptTree {
+					t.Fatalf("invalid panic, expected %v and received %v", ErrCorruptTree, err)
+				}
+			}()
+
+			tt.fn(w)
+		})
+	}
+}
+
+func TestNewRawInvalid(t *testing.T) {
+	w := New(1024)
+	w.Put([]byte("hello"), []byte("world"))
+
+	// Trees written with the same layout can be reopened
+	bs := backend.Bytes(append([]byte(nil), w.bs...))
+	r, err := NewRaw(1024, &bs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if val := string(r.Get([]byte("hello"))); val != "world" {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "world", val)
+	}
+
+	// Trunks written without the magic are rejected
+	bs = backend.Bytes(append([]byte(nil), w.bs...))
+	copy(bs, []byte{0xff, 0xff, 0xff, 0xff})
+	if _, err = NewRaw(1024, &bs); err != ErrInvalidFile {
+		t.Fatalf("invalid error, expected %v and received %v", ErrInvalidFile, err)
+	}
+
+	bs = backend.Bytes(append([]byte(nil), w.bs...))
+	(*trunk)(unsafe.Pointer(&bs[0])).version++
+	if _, err = NewRaw(1024, &bs); err != ErrUnsupportedVersion {
+		t.Fatalf("invalid error, expected %v and received %v", ErrUnsupportedVersion, err)
+	}
+
+	// Trees written before layout versions were recorded start with the legacy trunk, followed by their blocks
+	bs = make(backend.Bytes, 1024)
+	*(*legacyTrunk)(unsafe.Pointer(&bs[0])) = legacyTrunk{root: 32, cnt: 1, tail: 120, cap: 1024}
+	copy(bs[32:120], bytes.Repeat([]byte{1}, 88))
+	if _, err = NewRaw(1024, &bs); err != ErrLegacyFile {
+		t.Fatalf("invalid error, expected %v and received %v", ErrLegacyFile, err)
+	}
+}
+
+func TestAbbreviatedKeys(t *testing.T) {
+	// Keys which tie on their abbreviation, including zero bytes which match the abbreviation's padding
+	keys := []string{"", "\x00", "\x00\x00", "a", "a\x00", "a\x00\x01", "abcdefgh", "abcdefgh\x00", "abcdefghi", "abcdefgz"}
+	w := New(1024)
+	for _, i := range rand.Perm(len(keys)) {
+		w.Put([]byte(keys[i]), []byte(keys[i]))
+	}
+
+	if err := testValidate(w); err != nil {
+		t.Fatal(err)
+	}
+
+	var i int
+	w.ForEach(func(key, val []byte) (end bool) {
+		if string(key) != keys[i] || string(val) != keys[i] {
+			t.Fatalf("invalid key, expected %q and received %q", keys[i], key)
+		}
+
+		i++
+		return
+	})
+
+	if i != len(keys) {
+		t.Fatalf("invalid number of keys, expected %d and received %d", len(keys), i)
+	}
+
+	for _, key := range keys {
+		if string(w.Get([]byte(key))) != key {
+			t.Fatalf("invalid value for %q", key)
+		}
+	}
+}
+
 func TestBasic(t *testing.T) {
 	var err error
 	if err = os.MkdirAll("./test_data", 0755); err != nil {
@@ -223,6 +853,28 @@ func BenchmarkTreeGet(b *testing.B) {
 	b.ReportAllocs()
 }
 
+func BenchmarkTreeRandomGet(b *testing.B) {
+	benchGet(b, testRandomListStr)
+	b.ReportAllocs()
+}
+
+// BenchmarkTreeRecursiveGet measures lookups using a recursive search, as a baseline for BenchmarkTreeRandomGet
+func BenchmarkTreeRecursiveGet(b *testing.B) {
+	tr := New(1024 * 1024)
+	for _, kv := range testRandomListStr {
+		tr.Put(kv.Val, kv.Val)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, kv := range testRandomListStr {
+			testVal = tr.getValue(tr.getBlock(testSeekRecursive(tr, tr.getHeader().root, kv.Val)))
+		}
+	}
+
+	b.ReportAllocs()
+}
+
 func BenchmarkTreeSortedGetPut(b *testing.B) {
 	benchGetPut(b, testSortedListStr)
 	b.ReportAllocs()
@@ -248,6 +900,11 @@ func BenchmarkTreeForEach(b *testing.B) {
 	b.ReportAllocs()
 }
 
+func BenchmarkTreeForEachKey(b *testing.B) {
+	benchForEachKey(b, testSortedListStr)
+	b.ReportAllocs()
+}
+
 func BenchmarkTreeMMapGet(b *testing.B) {
 	benchMMAPGet(b, testSortedListStr)
 	b.ReportAllocs()
@@ -312,6 +969,23 @@ func testPut(t *testing.T, s []int) {
 	}
 }
 
+// testSeekRecursive is the recursive form of seekBlock
+func testSeekRecursive(tr *Tree, offset int64, key []byte) int64 {
+	if offset == -1 {
+		return -1
+	}
+
+	b := tr.getBlock(offset)
+	switch tr.compareKey(key, b) {
+	case 1:
+		return testSeekRecursive(tr, b.getChild(1), key)
+	case -1:
+		return testSeekRecursive(tr, b.getChild(0), key)
+	}
+
+	return offset
+}
+
 func benchGet(b *testing.B, s []testUtils.KV) {
 	tr := New(1024 * 1024)
 	for _, kv := range s {
@@ -365,6 +1039,22 @@ func benchForEach(b *testing.B, s []testUtils.KV) {
 	}
 }
 
+func benchForEachKey(b *testing.B, s []testUtils.KV) {
+	tr := New(1024 * 1024)
+
+	for _, kv := range s {
+		tr.Put(kv.Val, kv.Val)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		tr.ForEachKey(func(key []byte) (end bool) {
+			testVal = key
+			return
+		})
+	}
+}
+
 func benchMMAPGet(b *testing.B, s []testUtils.KV) {
 	var err error
 	if err = os.MkdirAll("./test_data", 0755); err != nil {
@@ -454,3 +1144,47 @@ func benchMMAPForEach(b *testing.B, s []testUtils.KV) {
 		})
 	}
 }
+
+// testValidate will ensure the tree satisfies the red-black properties
+func testValidate(tr *Tree) (err error) {
+	if root := tr.getBlock(tr.t.root); root != nil && root.c != colorBlack {
+		return errors.New("invalid root color, expected black")
+	}
+
+	_, err = testValidateBlock(tr, tr.t.root, -1)
+	return
+}
+
+func testValidateBlock(tr *Tree, offset, parent int64) (blackLevel int, err error) {
+	b := tr.getBlock(offset)
+	if b == nil {
+		return
+	}
+
+	if b.getParent() != parent {
+		return 0, fmt.Errorf("invalid parent for \"%s\"", tr.getKey(b))
+	}
+
+	if b.c == colorRed && (isRed(tr.getBlock(b.getChild(0))) || isRed(tr.getBlock(b.getChild(1)))) {
+		return 0, fmt.Errorf("invalid color for \"%s\", red block has a red child", tr.getKey(b))
+	}
+
+	var left, right int
+	if left, err = testValidateBlock(tr, b.getChild(0), offset); err != nil {
+		return
+	}
+
+	if right, err = testValidateBlock(tr, b.getChild(1), offset); err != nil {
+		return
+	}
+
+	if left != right {
+		return 0, fmt.Errorf("invalid black level for \"%s\", %d (left) / %d (right)", tr.getKey(b), left, right)
+	}
+
+	if blackLevel = left; b.c == colorBlack {
+		blackLevel++
+	}
+
+	return
+}
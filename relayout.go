@@ -0,0 +1,230 @@
+package rbt
+
+import "github.com/missionMeteora/toolkit/errors"
+
+const (
+	// ErrInvalidLayout is returned when an unknown layout is provided
+	ErrInvalidLayout = errors.Error("invalid layout")
+)
+
+const (
+	// LayoutBreadthFirst places blocks level by level, keeping the upper levels of the tree together
+	LayoutBreadthFirst Layout = iota
+	// LayoutVanEmdeBoas recursively places small subtrees together, so each page holds several levels of
+	// a search path regardless of the page size
+	LayoutVanEmdeBoas
+	// LayoutInOrder places blocks in key order, which suits scan heavy workloads
+	LayoutInOrder
+)
+
+// colocateSize is the largest blob (in bytes) which Relayout places directly after it's block. Larger blobs
+// would spread the blocks of the upper levels across many pages, they are placed after all of the blocks.
+const colocateSize = 256
+
+// Layout represents an order to place blocks in
+type Layout uint8
+
+// Relayout will rewrite the blocks of the tree into a single contiguous region in the provided order, so
+// that blocks which are accessed together share pages. The space held by the previous blocks is released.
+// Blocks are followed by blobs of up to 256 bytes, so stored keys share pages with their blocks. Larger blobs
+// are placed after the blocks in the same order, and values which meet the OverflowThreshold remain within
+// their overflow extents.
+// Note: The contents of buckets are not included and are laid out separately. Block and blob offsets change,
+// so outstanding value readers and bucket handles are invalidated.
+func (t *Tree) Relayout(order Layout) (err error) {
+	var offsets []int64
+	root := t.getHeader().root
+	switch order {
+	case LayoutBreadthFirst:
+		offsets = t.appendBreadthFirst(offsets, root)
+	case LayoutVanEmdeBoas:
+		offsets = t.appendVanEmdeBoas(offsets, root, t.getHeight(root))
+	case LayoutInOrder:
+		for offset := t.getHead(root); offset != -1; offset = t.getNext(offset) {
+			offsets = append(offsets, offset)
+		}
+	default:
+		return ErrInvalidLayout
+	}
+
+	if len(offsets) == 0 {
+		return
+	}
+
+	var sz, blobsSize int64
+	for _, offset := range offsets {
+		b := t.getBlock(offset)
+		sz += t.getEntrySize(b)
+		if t.hasBlob(b) && !isColocated(t.getBlobSize(b)) {
+			blobsSize += t.getBlobSize(b)
+		}
+	}
+
+	start, _ := t.alloc(sz + blobsSize)
+	// Copy each block into place followed by it's blob, the previous block is left pointing to it's copy.
+	// Blobs which are too large to be placed with their block are placed after all of the blocks.
+	cursor, blobCursor := start, start+sz
+	for _, offset := range offsets {
+		prev := t.getBlock(offset)
+		bsz := t.getBlockSize(prev)
+		copy(t.bs[cursor:cursor+bsz], t.bs[offset:offset+bsz])
+
+		b := t.getBlock(cursor)
+		b.setOffset(cursor)
+		if t.hasBlob(prev) {
+			boffset, blobSize := prev.getBlobOffset(), t.getBlobSize(prev)
+			dst := cursor + bsz
+			if !isColocated(blobSize) {
+				dst = blobCursor
+				blobCursor += blobSize
+			}
+
+			copy(t.bs[dst:dst+blobSize], t.bs[boffset:boffset+blobSize])
+			b.setBlobOffset(dst)
+		}
+
+		prev.setOffset(cursor)
+		cursor += t.getEntrySize(b)
+	}
+
+	// Point the copies to each other using the references left by the previous blocks
+	for cursor = start; cursor < start+sz; {
+		b := t.getBlock(cursor)
+		b.setParent(t.getForward(b.getParent()))
+		b.setChild(0, t.getForward(b.getChild(0)))
+		b.setChild(1, t.getForward(b.getChild(1)))
+		cursor += t.getEntrySize(b)
+	}
+
+	t.getHeader().root = t.getForward(root)
+
+	// Release the previous blocks and blobs
+	for _, offset := range offsets {
+		b := t.getBlock(offset)
+		if t.hasBlob(b) {
+			t.free(b.getBlobOffset(), t.getBlobSize(b))
+		}
+
+		t.free(offset, t.getBlockSize(b))
+	}
+
+	return
+}
+
+// getEntrySize will return the number of bytes Relayout places for a block, which includes the blob when it
+// is placed alongside the block
+func (t *Tree) getEntrySize(b *Block) (sz int64) {
+	sz = t.getBlockSize(b)
+	if !t.hasBlob(b) {
+		return
+	}
+
+	if blobSize := t.getBlobSize(b); isColocated(blobSize) {
+		sz += blobSize
+	}
+
+	return
+}
+
+// isColocated will return whether or not Relayout places a blob of the provided size alongside it's block
+func isColocated(blobSize int64) bool {
+	return blobSize <= colocateSize
+}
+
+// getForward will return the offset of the copy of a block which has been moved by Relayout
+func (t *Tree) getForward(offset int64) int64 {
+	if offset == -1 {
+		return -1
+	}
+
+	return t.getBlock(offset).getOffset()
+}
+
+// appendBreadthFirst will append the offsets of a tree level by level
+func (t *Tree) appendBreadthFirst(dst []int64, root int64) []int64 {
+	if root == -1 {
+		return dst
+	}
+
+	start := len(dst)
+	dst = append(dst, root)
+	for depth := 0; start < len(dst); depth++ {
+		checkDepth(depth)
+		// Append the children of the current level, which become the next level
+		end := len(dst)
+		for _, offset := range dst[start:end] {
+			b := t.getBlock(offset)
+			for _, child := range b.getChildren() {
+				if child != -1 {
+					dst = append(dst, child)
+				}
+			}
+		}
+
+		start = end
+	}
+
+	return dst
+}
+
+// appendVanEmdeBoas will append the offsets of a tree of the provided height in van Emde Boas order. The
+// top half of the tree is placed first, followed by each of the subtrees hanging beneath it.
+func (t *Tree) appendVanEmdeBoas(dst []int64, root int64, height int) []int64 {
+	if root == -1 {
+		return dst
+	}
+
+	if height <= 1 {
+		return append(dst, root)
+	}
+
+	top := height / 2
+	dst = t.appendVanEmdeBoas(dst, root, top)
+
+	// Acquire the roots of the subtrees hanging beneath the top half
+	level := []int64{root}
+	for depth := 0; depth < top; depth++ {
+		var next []int64
+		for _, offset := range level {
+			b := t.getBlock(offset)
+			for _, child := range b.getChildren() {
+				if child != -1 {
+					next = append(next, child)
+				}
+			}
+		}
+
+		level = next
+	}
+
+	for _, offset := range level {
+		dst = t.appendVanEmdeBoas(dst, offset, height-top)
+	}
+
+	return dst
+}
+
+// getHeight will return the number of levels within a tree
+func (t *Tree) getHeight(root int64) (height int) {
+	if root == -1 {
+		return
+	}
+
+	level := []int64{root}
+	for ; len(level) > 0; height++ {
+		checkDepth(height)
+		var next []int64
+		for _, offset := range level {
+			b := t.getBlock(offset)
+			for _, child := range b.getChildren() {
+				if child != -1 {
+					next = append(next, child)
+				}
+			}
+		}
+
+		level = next
+	}
+
+	return
+}
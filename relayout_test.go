@@ -0,0 +1,178 @@
+package rbt
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestRelayout(t *testing.T) {
+	for _, order := range []Layout{LayoutBreadthFirst, LayoutVanEmdeBoas, LayoutInOrder} {
+		var err error
+		w := New(1024)
+		w.SetPrefixCompression(true)
+		rnd := rand.New(rand.NewSource(int64(order)))
+		vals := make(map[string][]byte)
+		for _, i := range rnd.Perm(1000) {
+			key := fmt.Sprintf("prefixed-key-%04d", i)
+			// Alternate between inline, external and large external values
+			val := bytes.Repeat([]byte{byte(i)}, 1+i%3*40)
+			if i%10 == 0 {
+				val = bytes.Repeat([]byte{byte(i)}, 1000)
+			}
+
+			vals[key] = val
+			w.Put([]byte(key), val)
+		}
+
+		if err = w.Relayout(order); err != nil {
+			t.Fatal(err)
+		}
+
+		if err = testValidate(w); err != nil {
+			t.Fatal(err)
+		}
+
+		var cnt int
+		prev := int64(-1)
+		w.ForEach(func(key, val []byte) (end bool) {
+			if !bytes.Equal(val, vals[string(key)]) {
+				t.Fatalf("invalid value for \"%s\"", key)
+			}
+
+			cnt++
+			return
+		})
+
+		if cnt != len(vals) {
+			t.Fatalf("invalid number of entries, expected %d and received %d", len(vals), cnt)
+		}
+
+		root := w.getHeader().root
+		var last int64
+		for offset := w.getHead(root); offset != -1; offset = w.getNext(offset) {
+			last = max(last, offset)
+		}
+
+		for offset := w.getHead(root); offset != -1; offset = w.getNext(offset) {
+			b := w.getBlock(offset)
+			switch {
+			case !w.hasBlob(b):
+			case isColocated(w.getBlobSize(b)):
+				// Small blobs directly follow their blocks
+				if b.getBlobOffset() != offset+w.getBlockSize(b) {
+					t.Fatalf("invalid layout, expected blob of %d to follow it's block", offset)
+				}
+
+			case b.getBlobOffset() <= last:
+				// Large blobs follow every block
+				t.Fatalf("invalid layout, expected blob of %d to follow the blocks", offset)
+			}
+		}
+
+		switch order {
+		case LayoutBreadthFirst, LayoutVanEmdeBoas:
+			// The root leads the region for both layouts
+			for offset := w.getHead(root); offset != -1; offset = w.getNext(offset) {
+				if offset < root {
+					t.Fatalf("invalid layout, expected root (%d) to precede %d", root, offset)
+				}
+			}
+
+		case LayoutInOrder:
+			for offset := w.getHead(root); offset != -1; offset = w.getNext(offset) {
+				if offset <= prev {
+					t.Fatalf("invalid layout, expected %d to follow %d", offset, prev)
+				}
+
+				prev = offset
+			}
+		}
+
+		// The tree remains writable after being laid out
+		for key := range vals {
+			w.Delete([]byte(key))
+		}
+
+		if err = testValidate(w); err != nil {
+			t.Fatal(err)
+		}
+
+		if w.Len() != 0 {
+			t.Fatalf("invalid length, expected %d and received %d", 0, w.Len())
+		}
+	}
+}
+
+func TestRelayoutInvalid(t *testing.T) {
+	var err error
+	w := New(1024)
+	if err = w.Relayout(LayoutVanEmdeBoas); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = w.Relayout(Layout(255)); err != ErrInvalidLayout {
+		t.Fatalf("invalid error, expected %v and received %v", ErrInvalidLayout, err)
+	}
+}
+
+func BenchmarkRelayoutNoneGet(b *testing.B) {
+	benchmarkRelayoutGet(b, false, 0, 1000000, InlineSize)
+}
+
+func BenchmarkRelayoutBreadthFirstGet(b *testing.B) {
+	benchmarkRelayoutGet(b, true, LayoutBreadthFirst, 1000000, InlineSize)
+}
+
+func BenchmarkRelayoutVanEmdeBoasGet(b *testing.B) {
+	benchmarkRelayoutGet(b, true, LayoutVanEmdeBoas, 1000000, InlineSize)
+}
+
+func BenchmarkRelayoutInOrderGet(b *testing.B) {
+	benchmarkRelayoutGet(b, true, LayoutInOrder, 1000000, InlineSize)
+}
+
+func BenchmarkRelayoutNoneGetLarge(b *testing.B) {
+	benchmarkRelayoutGet(b, false, 0, 200000, 2048)
+}
+
+func BenchmarkRelayoutBreadthFirstGetLarge(b *testing.B) {
+	benchmarkRelayoutGet(b, true, LayoutBreadthFirst, 200000, 2048)
+}
+
+func BenchmarkRelayoutVanEmdeBoasGetLarge(b *testing.B) {
+	benchmarkRelayoutGet(b, true, LayoutVanEmdeBoas, 200000, 2048)
+}
+
+func BenchmarkRelayoutInOrderGetLarge(b *testing.B) {
+	benchmarkRelayoutGet(b, true, LayoutInOrder, 200000, 2048)
+}
+
+// benchmarkRelayoutGet will benchmark random gets of n keys sharing a long prefix, so comparisons reach the
+// stored keys. Values are too large to be held inline and the tree is sized to exceed the CPU caches.
+func benchmarkRelayoutGet(b *testing.B, relayout bool, order Layout, n, vsz int) {
+	rnd := rand.New(rand.NewSource(1))
+	keys := make([][]byte, n)
+	val := bytes.Repeat([]byte("v"), vsz)
+	w := New(int64(n) * int64(256+vsz))
+	for i, j := range rnd.Perm(n) {
+		keys[i] = []byte(fmt.Sprintf("relayout/benchmark/%08d", j))
+		w.Put(keys[i], val)
+	}
+
+	if relayout {
+		if err := w.Relayout(order); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	rnd.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		w.Get(keys[i%n])
+	}
+
+	b.ReportAllocs()
+}
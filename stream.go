@@ -0,0 +1,235 @@
+package rbt
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/missionMeteora/toolkit/errors"
+)
+
+// ValueReader will return a reader for the value stored for a key, ErrKeyNotFound is returned if the key does not exist
+// and ErrIncompatibleValue is returned if the key holds a bucket. An error is returned when an encoded value cannot
+// be decoded.
+// Note: Reads are served directly from the tree's storage (encoded values are decoded up front).
+// The reader is invalidated once the key is modified or deleted.
+func (t *Tree) ValueReader(key []byte) (r *io.SectionReader, err error) {
+	offset := t.seekBlock(t.getHeader().root, key)
+	if offset == -1 {
+		err = ErrKeyNotFound
+		return
+	}
+
+	b := t.getBlock(offset)
+	if b.flags&blockBucket != 0 {
+		err = ErrIncompatibleValue
+		return
+	}
+
+	if isEncoded(b) {
+		// Encoded values are read from their decoded copy
+		var val []byte
+		if val, err = t.decodeValue(b); err != nil {
+			return
+		}
+
+		return io.NewSectionReader(bytes.NewReader(val), 0, int64(len(val))), nil
+	}
+
+	var vr valueReader
+	vr.t = t
+	vr.offset = offset
+	return io.NewSectionReader(&vr, 0, t.getValLen(b)), nil
+}
+
+// ValueWriter will return a writer which streams a new value for a key. Size bytes are preallocated,
+// writing beyond size will grow the allocation. The value is stored (replacing any existing value) once
+// the writer is closed, the writer must be closed for the allocation to be retained by the tree.
+// Note: While encryption is enabled, the value is buffered in memory and sealed once the writer is closed
+func (t *Tree) ValueWriter(key []byte, size int64) io.WriteCloser {
+	var vw valueWriter
+	vw.t = t
+	vw.key = append([]byte(nil), key...)
+	if t.keys != nil {
+		vw.buf = make([]byte, 0, size)
+		vw.sealed = true
+		return &vw
+	}
+
+	vw.alloc(size)
+	return &vw
+}
+
+type valueReader struct {
+	t *Tree
+	// Offset of the block
+	offset int64
+}
+
+// ReadAt will read from the value at the provided offset
+func (v *valueReader) ReadAt(p []byte, off int64) (n int, err error) {
+	val := v.t.getValue(v.t.getBlock(v.offset))
+	if off >= int64(len(val)) {
+		return 0, io.EOF
+	}
+
+	if n = copy(p, val[off:]); n < len(p) {
+		err = io.EOF
+	}
+
+	return
+}
+
+type valueWriter struct {
+	t   *Tree
+	key []byte
+
+	// Offset of the allocation, this is an overflow extent for values which meet the OverflowThreshold
+	offset int64
+	// Capacity and length of the value
+	cap int64
+	len int64
+
+	// Buffer holding the value of sealed writers, which never write the value to storage unencrypted
+	buf []byte
+
+	overflow bool
+	sealed   bool
+	closed   bool
+}
+
+// Write will write to the end of the value
+func (v *valueWriter) Write(p []byte) (n int, err error) {
+	if v.closed {
+		return 0, errors.ErrIsClosed
+	}
+
+	if v.sealed {
+		v.buf = append(v.buf, p...)
+		return len(p), nil
+	}
+
+	sz := v.len + int64(len(p))
+	if sz > v.cap {
+		if v.t.isStorage(p) {
+			// Allocating may remap our storage, copy data which references it
+			p = append([]byte(nil), p...)
+		}
+
+		v.grow(sz)
+	}
+
+	n = copy(v.t.bs[v.offset+v.len:], p)
+	v.len = sz
+	return
+}
+
+// Close will store the written value for the key
+func (v *valueWriter) Close() (err error) {
+	if v.closed {
+		return errors.ErrIsClosed
+	}
+
+	v.closed = true
+
+	t := v.t
+	if v.sealed {
+		if b := t.getBlock(t.seekBlock(t.getHeader().root, v.key)); b != nil && b.flags&blockBucket != 0 {
+			return ErrIncompatibleValue
+		}
+
+		t.Put(v.key, v.buf)
+		return
+	}
+
+	// Zero any unused capacity
+	clear(t.bs[v.offset+v.len : v.offset+v.getAllocSize()])
+
+	// Our value has already been allocated, the block is not created inline
+	offset := t.createBlock(v.key, -1)
+	b := t.getBlock(offset)
+	if b.flags&blockBucket != 0 {
+		v.free()
+		return ErrIncompatibleValue
+	}
+
+	created := !b.hasValue()
+
+	blobLen := t.getStoredLen(b, v.key)
+	if !v.overflow {
+		// Values which do not overflow are stored alongside the key
+		blobLen += v.cap
+	}
+
+	boffset, grew := t.keyBlob(b, v.key, blobLen, v.overflow)
+	if grew {
+		b = t.getBlock(offset)
+	}
+
+	ooffset := int64(-1)
+	if v.overflow {
+		ooffset = v.offset
+	} else {
+		copy(t.bs[boffset+getBlobHeaderSize(b.flags)+t.getStoredLen(b, v.key):], t.bs[v.offset:v.offset+v.cap])
+		v.free()
+	}
+
+	// Release the previous allocation and replace it with our allocation
+	t.releaseBlob(b, boffset)
+	b.setBlobOffset(boffset)
+	b.flags &^= blockCompressed | blockEncrypted
+	t.setBlobSlot(b, blobOverflow, ooffset)
+	t.setBlobSlot(b, blobValLen, v.len)
+	t.setBlobSlot(b, blobValCap, v.cap)
+
+	if created {
+		t.insertBalance(b)
+	}
+
+	return
+}
+
+func (v *valueWriter) alloc(vcap int64) {
+	v.cap = vcap
+	if v.overflow = isOverflow(vcap); v.overflow {
+		v.offset, _ = v.t.allocOverflow(v.getAllocSize())
+		return
+	}
+
+	v.offset, _ = v.t.alloc(v.getAllocSize())
+}
+
+func (v *valueWriter) grow(sz int64) {
+	vcap := v.cap
+	if vcap == 0 {
+		vcap = sz
+	}
+
+	for vcap < sz {
+		vcap *= 2
+	}
+
+	prev := *v
+	v.alloc(vcap)
+
+	// Move the written bytes to the new allocation and release the previous allocation
+	copy(v.t.bs[v.offset:], v.t.bs[prev.offset:prev.offset+v.len])
+	prev.free()
+}
+
+func (v *valueWriter) free() {
+	if v.overflow {
+		v.t.freeOverflow(v.offset, v.getAllocSize())
+		return
+	}
+
+	v.t.free(v.offset, v.getAllocSize())
+}
+
+// getAllocSize will return the number of bytes allocated by the writer
+func (v *valueWriter) getAllocSize() int64 {
+	if v.overflow {
+		return getExtentSize(v.cap)
+	}
+
+	return v.cap
+}
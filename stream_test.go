@@ -0,0 +1,105 @@
+package rbt
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func TestValueWriter(t *testing.T) {
+	w := New(1024)
+	w.Put([]byte("blob"), []byte("previous value"))
+
+	expected := make([]byte, 3*1024*1024+7)
+	rand.Read(expected)
+
+	vw := w.ValueWriter([]byte("blob"), 1024)
+	if _, err := io.Copy(vw, bytes.NewReader(expected)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Value is not replaced until the writer is closed
+	if val := string(w.Get([]byte("blob"))); val != "previous value" {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "previous value", val)
+	}
+
+	if err := vw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := vw.Write([]byte("closed")); err == nil {
+		t.Fatal("invalid error, expected an error when writing to a closed writer")
+	}
+
+	if val := w.Get([]byte("blob")); !bytes.Equal(val, expected) {
+		t.Fatalf("invalid value, expected %d bytes and received %d bytes", len(expected), len(val))
+	}
+
+	vw = w.ValueWriter([]byte("new"), 16)
+	vw.Write([]byte("hello "))
+	vw.Write([]byte("world"))
+	vw.Close()
+
+	if val := string(w.Get([]byte("new"))); val != "hello world" {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "hello world", val)
+	}
+
+	if w.Len() != 2 {
+		t.Fatalf("invalid length, expected %d and received %d", 2, w.Len())
+	}
+}
+
+func TestValueReader(t *testing.T) {
+	var err error
+	if err = os.MkdirAll("./test_data", 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll("./test_data")
+
+	var tr *Tree
+	if tr, err = NewMMAP("./test_data", "mmap.db", 64); err != nil {
+		t.Fatal(err)
+	}
+	defer tr.Close()
+
+	if _, err = tr.ValueReader([]byte("blob")); err != ErrKeyNotFound {
+		t.Fatalf("invalid error, expected %v and received %v", ErrKeyNotFound, err)
+	}
+
+	expected := make([]byte, 1024*1024)
+	rand.Read(expected)
+	tr.Put([]byte("blob"), expected)
+
+	r, err := tr.ValueReader([]byte("blob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Growing the tree between reads should not invalidate the reader
+	buf := make([]byte, 1000)
+	if _, err = io.ReadFull(r, buf); err != nil {
+		t.Fatal(err)
+	}
+
+	tr.Put([]byte("filler"), make([]byte, 4*1024*1024))
+
+	var out bytes.Buffer
+	out.Write(buf)
+	if _, err = io.Copy(&out, r); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(out.Bytes(), expected) {
+		t.Fatalf("invalid value, expected %d bytes and received %d bytes", len(expected), out.Len())
+	}
+
+	if _, err = r.Seek(-10, io.SeekEnd); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, _ := r.Read(buf); n != 10 || !bytes.Equal(buf[:10], expected[len(expected)-10:]) {
+		t.Fatalf("invalid read, expected the final %d bytes and received %d bytes", 10, n)
+	}
+}
@@ -0,0 +1,100 @@
+package rbt
+
+// NewTyped will return a new Typed wrapper around the provided tree
+func NewTyped[K, V any](t *Tree, kc KeyCodec[K], vc ValueCodec[V]) *Typed[K, V] {
+	var tt Typed[K, V]
+	tt.t = t
+	tt.kc = kc
+	tt.vc = vc
+	return &tt
+}
+
+// Typed wraps a Tree and encodes/decodes keys and values using the provided codecs
+type Typed[K, V any] struct {
+	t *Tree
+
+	kc KeyCodec[K]
+	vc ValueCodec[V]
+
+	// Scratch buffers, reused between calls
+	kbuf []byte
+	vbuf []byte
+}
+
+// Get will retrieve an item from the tree, ErrKeyNotFound is returned if the key does not exist
+func (t *Typed[K, V]) Get(key K) (val V, err error) {
+	// Empty values may be read as nil, existence is taken from the lookup itself
+	bs, ok := t.t.get(t.encodeKey(key))
+	if !ok {
+		err = ErrKeyNotFound
+		return
+	}
+
+	return t.vc.DecodeValue(bs)
+}
+
+// Put will insert an item into the tree
+func (t *Typed[K, V]) Put(key K, val V) (err error) {
+	if t.vbuf, err = t.vc.EncodeValue(t.vbuf[:0], val); err != nil {
+		return
+	}
+
+	t.t.Put(t.encodeKey(key), t.vbuf)
+	return
+}
+
+// Delete will remove an item from the tree
+func (t *Typed[K, V]) Delete(key K) {
+	t.t.Delete(t.encodeKey(key))
+}
+
+// ForEach will iterate through each tree item
+// Note: Iteration stops at the first key or value which cannot be decoded
+func (t *Typed[K, V]) ForEach(fn TypedForEachFn[K, V]) (err error) {
+	t.t.ForEach(t.forEachFn(fn, &err))
+	return
+}
+
+// Range will iterate through each tree item within the range of [start, end)
+// Note: Iteration stops at the first key or value which cannot be decoded
+func (t *Typed[K, V]) Range(start, end K, fn TypedForEachFn[K, V]) (err error) {
+	// Encode into empty (non-nil) slices, a nil bound would be treated as unbounded
+	kstart := t.kc.EncodeKey([]byte{}, start)
+	kend := t.kc.EncodeKey([]byte{}, end)
+	t.t.iterateRange(kstart, kend, t.forEachFn(fn, &err))
+	return
+}
+
+// Len will return the number of items within the tree
+func (t *Typed[K, V]) Len() (n int) {
+	return t.t.Len()
+}
+
+// Tree will return the underlying tree
+func (t *Typed[K, V]) Tree() *Tree {
+	return t.t
+}
+
+func (t *Typed[K, V]) encodeKey(key K) []byte {
+	t.kbuf = t.kc.EncodeKey(t.kbuf[:0], key)
+	return t.kbuf
+}
+
+func (t *Typed[K, V]) forEachFn(fn TypedForEachFn[K, V], err *error) ForEachFn {
+	return func(kbs, vbs []byte) (end bool) {
+		var (
+			key K
+			val V
+		)
+
+		if key, *err = t.kc.DecodeKey(kbs); *err != nil {
+			return true
+		}
+
+		if val, *err = t.vc.DecodeValue(vbs); *err != nil {
+			return true
+		}
+
+		return fn(key, val)
+	}
+}
@@ -0,0 +1,182 @@
+package rbt
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+type testDocument struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type testPoint struct {
+	X int32
+	Y int32
+}
+
+func TestTypedIntKeys(t *testing.T) {
+	tt := NewTyped[int64, string](New(1024), IntKeys[int64]{}, JSONValues[string]{})
+	for i := int64(-500); i < 500; i += 7 {
+		if err := tt.Put(i, "value"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	last := int64(-501)
+	if err := tt.ForEach(func(key int64, val string) (end bool) {
+		if key <= last {
+			t.Fatalf("invalid key order, %d was received after %d", key, last)
+		}
+
+		last = key
+		return
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var cnt int
+	if err := tt.Range(-10, 10, func(key int64, val string) (end bool) {
+		if key < -10 || key >= 10 {
+			t.Fatalf("invalid key, %d is outside of the range", key)
+		}
+
+		cnt++
+		return
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if cnt != 3 {
+		t.Fatalf("invalid number of iterations, expected %d and received %d", 3, cnt)
+	}
+
+	tt.Delete(-500)
+	if _, err := tt.Get(-500); err != ErrKeyNotFound {
+		t.Fatalf("invalid error, expected %v and received %v", ErrKeyNotFound, err)
+	}
+}
+
+func TestTypedUintKeys(t *testing.T) {
+	tt := NewTyped[uint32, testPoint](New(1024), UintKeys[uint32]{}, BinaryValues[testPoint]{})
+	for i := uint32(0); i < 300; i++ {
+		if err := tt.Put(i*i, testPoint{X: int32(i), Y: -int32(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, err := tt.Get(289 * 289)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.X != 289 || p.Y != -289 {
+		t.Fatalf("invalid value, expected %v and received %v", testPoint{X: 289, Y: -289}, p)
+	}
+
+	var last uint32
+	if err = tt.ForEach(func(key uint32, val testPoint) (end bool) {
+		if key < last {
+			t.Fatalf("invalid key order, %d was received after %d", key, last)
+		}
+
+		last = key
+		return
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTypedTimeKeys(t *testing.T) {
+	tt := NewTyped[time.Time, testDocument](New(1024), TimeKeys{}, GobValues[testDocument]{})
+	base := time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 100; i++ {
+		key := base.Add(time.Duration(i) * 365 * 24 * time.Hour).Add(time.Duration(i))
+		if err := tt.Put(key, testDocument{Name: key.String(), Count: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var cnt int
+	if err := tt.ForEach(func(key time.Time, val testDocument) (end bool) {
+		if val.Count != cnt {
+			t.Fatalf("invalid value order, expected %d and received %d", cnt, val.Count)
+		}
+
+		if val.Name != key.String() {
+			t.Fatalf("invalid key, expected %s and received %s", val.Name, key.String())
+		}
+
+		cnt++
+		return
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if cnt != 100 {
+		t.Fatalf("invalid number of iterations, expected %d and received %d", 100, cnt)
+	}
+}
+
+func TestTypedStringKeys(t *testing.T) {
+	tt := NewTyped[string, []byte](New(1024), StringKeys[string]{}, RawValues{})
+	tt.Put("b", []byte("2"))
+	tt.Put("a", []byte("1"))
+	tt.Put("c", []byte("3"))
+
+	val, err := tt.Get("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Raw values are copied, modifying the tree should not modify the value
+	tt.Put("a", []byte("9"))
+	if string(val) != "1" {
+		t.Fatalf("invalid value, expected \"%s\" and received \"%s\"", "1", string(val))
+	}
+
+	var keys string
+	tt.Range("b", "d", func(key string, val []byte) (end bool) {
+		keys += key
+		return
+	})
+
+	if keys != "bc" {
+		t.Fatalf("invalid keys, expected \"%s\" and received \"%s\"", "bc", keys)
+	}
+
+	if tt.Len() != 3 {
+		t.Fatalf("invalid length, expected %d and received %d", 3, tt.Len())
+	}
+}
+
+func TestTypedEmptyValue(t *testing.T) {
+	tr := New(1024)
+	// Empty values are decrypted as nil
+	tr.SetKeyProvider(NewKeyring(1, bytes.Repeat([]byte("k"), 32)))
+
+	tt := NewTyped[string, []byte](tr, StringKeys[string]{}, RawValues{})
+	tt.Put("a", []byte{})
+	if val, err := tt.Get("a"); err != nil {
+		t.Fatal(err)
+	} else if len(val) != 0 {
+		t.Fatalf("invalid value, expected an empty value and received \"%s\"", val)
+	}
+
+	if _, err := tt.Get("b"); err != ErrKeyNotFound {
+		t.Fatalf("invalid error, expected %v and received %v", ErrKeyNotFound, err)
+	}
+}
+
+func TestTypedDecodeError(t *testing.T) {
+	tr := New(1024)
+	tr.Put([]byte("short"), []byte("{}"))
+
+	tt := NewTyped[uint64, testDocument](tr, UintKeys[uint64]{}, JSONValues[testDocument]{})
+	if err := tt.ForEach(func(key uint64, val testDocument) (end bool) {
+		return
+	}); err != ErrInvalidEncoding {
+		t.Fatalf("invalid error, expected %v and received %v", ErrInvalidEncoding, err)
+	}
+}
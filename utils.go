@@ -1,5 +1,10 @@
 package rbt
 
+import (
+	"encoding/binary"
+	"unsafe"
+)
+
 func isBlack(b *Block) bool {
 	if b == nil {
 		return true
@@ -15,3 +20,66 @@ func isRed(b *Block) bool {
 
 	return b.c == colorRed
 }
+
+// isInline will return whether or not a key and value of the provided sizes can be held inline by their Block
+func isInline(keyLen, vcap int64) bool {
+	return vcap >= 0 && keyLen+vcap <= InlineSize
+}
+
+// isEncoded will return whether or not a block's value has been compressed or encrypted
+func isEncoded(b *Block) bool {
+	return b.flags&(blockCompressed|blockEncrypted) != 0
+}
+
+// checkDepth will panic with ErrCorruptTree when a walk has exceeded the maximum depth of a valid tree
+func checkDepth(depth int) {
+	if depth >= maxDepth {
+		panic(ErrCorruptTree)
+	}
+}
+
+// getAbbr will return the first eight bytes of a key as a big-endian integer, shorter keys are zero padded.
+// Note: Unequal abbreviations order the same as their keys, equal abbreviations require a full comparison.
+func getAbbr(key []byte) (abbr uint64) {
+	if len(key) >= 8 {
+		return binary.BigEndian.Uint64(key)
+	}
+
+	for i, c := range key {
+		abbr |= uint64(c) << (56 - 8*uint(i))
+	}
+
+	return
+}
+
+// isZero will return whether or not every byte is zero
+func isZero(bs []byte) bool {
+	for _, b := range bs {
+		if b != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isLegacy will return whether or not the provided bytes start with a legacyTrunk. These trunks held no magic,
+// so they are recognized by a tail and root which are within bounds.
+func isLegacy(bs []byte) bool {
+	size := int64(unsafe.Sizeof(legacyTrunk{}))
+	if int64(len(bs)) < size {
+		return false
+	}
+
+	lt := (*legacyTrunk)(unsafe.Pointer(&bs[0]))
+	switch {
+	case lt.tail < size || lt.tail > int64(len(bs)):
+		return false
+	case lt.cnt < 0:
+		return false
+	case lt.root == -1:
+		return lt.cnt == 0
+	default:
+		return lt.root >= size && lt.root < lt.tail
+	}
+}